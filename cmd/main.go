@@ -58,6 +58,9 @@ func run() {
 	// Connect metronome outputs
 	m.AddOutputChannel(g.InputBeatChannel)
 
+	// Connect grid outputs
+	g.AddOutputChannel(c.InputGridChannel)
+
 	// Connect control outputs
 	c.AddOutputChannel(s.InputCtrlChannel)
 	c.AddOutputChannel(g.InputCtrlChannel)
@@ -0,0 +1,244 @@
+package generators
+
+// Pattern algebra. Every operation returns a new Pattern and leaves its
+// operands untouched. Binary operations run for the length of the longer
+// pattern, repeating the shorter one from its start. Accents, chances and
+// ratchets follow their hits; where hits combine, the greatest value wins.
+
+// NewPattern returns a pattern from a rhythm, any non-zero step being a hit
+func NewPattern(rhythm []uint8) *Pattern {
+	p := &Pattern{Rhythm: make([]uint8, len(rhythm))}
+	for i, v := range rhythm {
+		if v != 0 {
+			p.Rhythm[i] = 1
+		}
+	}
+	return p
+}
+
+// Clone returns a copy of p
+func (p *Pattern) Clone() *Pattern {
+	np := NewPattern(p.Rhythm)
+	for _, l := range stepLanes {
+		if values := *l.of(p); values != nil {
+			*l.of(np) = append([]uint8{}, values...)
+		}
+	}
+	return np
+}
+
+// AccentWith returns p with its hits accented to level by layer: the n-th
+// hit of p is accented when step n of layer, repeated as needed, is a hit.
+// A layer of E(3,8) over a pattern with 8 hits accents the 1st, 4th and 7th.
+func (p *Pattern) AccentWith(layer *Pattern, level uint8) *Pattern {
+	np := p.Clone()
+	n := 0
+	for i, v := range np.Rhythm {
+		if v == 1 {
+			if step(layer, n) == 1 {
+				np.SetAccent(i, level)
+			}
+			n++
+		}
+	}
+	return np
+}
+
+// Groove returns p with its hits pulled towards a denser euclidean rhythm
+// by amount (0-100), see NewEuclid
+func (p *Pattern) Groove(amount float64) *Pattern {
+	np := p.Clone()
+	if amount != 0 && len(np.Rhythm) > 0 && len(np.Rhythm) <= MaxSteps-1 {
+		np.setGroove(Bjorklund, uint8(np.Hits()), uint8(np.Len()), amount)
+	}
+	return np
+}
+
+// Len returns the number of steps in p
+func (p *Pattern) Len() int {
+	return len(p.Rhythm)
+}
+
+// Hits returns the number of steps in p that are on
+func (p *Pattern) Hits() int {
+	hits := 0
+	for _, v := range p.Rhythm {
+		hits += int(v)
+	}
+	return hits
+}
+
+// And returns a pattern with hits where both p and q have hits
+func (p *Pattern) And(q *Pattern) *Pattern {
+	return combine(p, q, func(a, b uint8) uint8 { return a & b })
+}
+
+// Or returns a pattern with hits where either p or q has a hit
+func (p *Pattern) Or(q *Pattern) *Pattern {
+	return combine(p, q, func(a, b uint8) uint8 { return a | b })
+}
+
+// Xor returns a pattern with hits where exactly one of p and q has a hit
+func (p *Pattern) Xor(q *Pattern) *Pattern {
+	return combine(p, q, func(a, b uint8) uint8 { return a ^ b })
+}
+
+// Not returns the inverse of p, every rest becoming a plain hit and every hit a rest
+func (p *Pattern) Not() *Pattern {
+	np := &Pattern{Rhythm: make([]uint8, len(p.Rhythm))}
+	for i, v := range p.Rhythm {
+		np.Rhythm[i] = v ^ 1
+	}
+	return np
+}
+
+// Concat returns p followed by q
+func (p *Pattern) Concat(q *Pattern) *Pattern {
+	np := &Pattern{Rhythm: make([]uint8, 0, len(p.Rhythm)+len(q.Rhythm))}
+	np.Rhythm = append(np.Rhythm, p.Rhythm...)
+	np.Rhythm = append(np.Rhythm, q.Rhythm...)
+	for _, l := range stepLanes {
+		if *l.of(p) != nil || *l.of(q) != nil {
+			*l.of(np) = append(l.values(p), l.values(q)...)
+		}
+	}
+	return np
+}
+
+// Reverse returns p played backwards
+func (p *Pattern) Reverse() *Pattern {
+	np := p.Clone()
+	for i, v := range p.Rhythm {
+		np.Rhythm[len(p.Rhythm)-1-i] = v
+	}
+	for _, l := range stepLanes {
+		if values := *l.of(p); values != nil {
+			for i, v := range values {
+				(*l.of(np))[len(values)-1-i] = v
+			}
+		}
+	}
+	return np
+}
+
+// Rotate returns p shifted left by steps, or right when steps is negative
+func (p *Pattern) Rotate(steps int) *Pattern {
+	np := p.Clone()
+	if steps != 0 && len(np.Rhythm) > 0 {
+		np.setRotate(steps)
+	}
+	return np
+}
+
+// Stretch returns p resized to length steps, moving each hit to its
+// proportional position. When compressing, hits landing on the same step merge.
+func (p *Pattern) Stretch(length int) *Pattern {
+
+	if length < 0 {
+		length = 0
+	}
+
+	np := &Pattern{Rhythm: make([]uint8, length)}
+	if len(p.Rhythm) == 0 || length == 0 {
+		return np
+	}
+
+	for _, l := range stepLanes {
+		if *l.of(p) != nil {
+			*l.of(np) = make([]uint8, length)
+		}
+	}
+
+	for i, v := range p.Rhythm {
+		if v == 1 {
+			j := i * length / len(p.Rhythm)
+			np.Rhythm[j] = 1
+			merge(np, j, p, i)
+		}
+	}
+
+	return np
+}
+
+// Nest returns p with every step subdivided by q: each hit is replaced by
+// q and each rest by as many rests. The step values of q are scaled by
+// those of the hit they replace, e.g. an accented hit accents all of q.
+func (p *Pattern) Nest(q *Pattern) *Pattern {
+
+	np := &Pattern{Rhythm: make([]uint8, 0, len(p.Rhythm)*len(q.Rhythm))}
+	rests := make([]uint8, len(q.Rhythm))
+	for _, v := range p.Rhythm {
+		if v == 1 {
+			np.Rhythm = append(np.Rhythm, q.Rhythm...)
+		} else {
+			np.Rhythm = append(np.Rhythm, rests...)
+		}
+	}
+
+	for _, l := range stepLanes {
+		if *l.of(p) == nil && *l.of(q) == nil {
+			continue
+		}
+		values := make([]uint8, 0, len(np.Rhythm))
+		for i := range p.Rhythm {
+			for j := range q.Rhythm {
+				values = append(values, l.scale(l.value(p, i), l.value(q, j)))
+			}
+		}
+		*l.of(np) = values
+	}
+
+	return np
+}
+
+// combine applies op step by step over the length of the longer pattern
+func combine(p, q *Pattern, op func(a, b uint8) uint8) *Pattern {
+
+	length := len(p.Rhythm)
+	if len(q.Rhythm) > length {
+		length = len(q.Rhythm)
+	}
+
+	np := &Pattern{Rhythm: make([]uint8, length)}
+	for i := range np.Rhythm {
+		np.Rhythm[i] = op(step(p, i), step(q, i))
+	}
+
+	for _, l := range stepLanes {
+		if *l.of(p) != nil || *l.of(q) != nil {
+			*l.of(np) = make([]uint8, length)
+		}
+	}
+
+	for i, v := range np.Rhythm {
+		if v == 0 {
+			continue
+		}
+		for _, operand := range []*Pattern{p, q} {
+			if step(operand, i) == 1 {
+				merge(np, i, operand, i%len(operand.Rhythm))
+			}
+		}
+	}
+
+	return np
+}
+
+// merge raises the step values of step i of np to those of step j of p
+func merge(np *Pattern, i int, p *Pattern, j int) {
+	for _, l := range stepLanes {
+		if values := *l.of(np); values != nil {
+			if v := l.value(p, j); v > values[i] {
+				values[i] = v
+			}
+		}
+	}
+}
+
+// step returns step i of p, repeating p from its start; an empty pattern is all rests
+func step(p *Pattern, i int) uint8 {
+	if len(p.Rhythm) == 0 {
+		return 0
+	}
+	return p.Rhythm[i%len(p.Rhythm)]
+}
@@ -0,0 +1,313 @@
+package generators
+
+import "testing"
+
+// parseRhythm reads a rhythm written with x for a pulse and . for a rest
+func parseRhythm(s string) *Pattern {
+	rhythm := make([]uint8, len(s))
+	for i := range s {
+		if s[i] == 'x' {
+			rhythm[i] = 1
+		}
+	}
+	return NewPattern(rhythm)
+}
+
+func TestCombine(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		p, q      string
+		and, or   string
+		xor, xorq string // p.Xor(q) and q.Xor(p)
+	}{
+		{"equal", "x.x.", "xx..", "x...", "xxx.", ".xx.", ".xx."},
+		{"empty", "x.x.", "", "....", "x.x.", "x.x.", "x.x."},
+		{"shorter q", "x..x..x.", "x.", "x.....x.", "x.xxx.x.", "..xxx...", "..xxx..."},
+		{"shorter p", "x.", "x..x..x.", "x.....x.", "x.xxx.x.", "..xxx...", "..xxx..."},
+		{"uneven", "x.x", "xx", "x.x", "xxx", ".x.", ".x."},
+		{"coprime", "x..", "x.", "x..", "x.x", "..x", "..x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, q := parseRhythm(tt.p), parseRhythm(tt.q)
+			for _, op := range []struct {
+				name string
+				got  *Pattern
+				want string
+			}{
+				{"And", p.And(q), tt.and},
+				{"reversed And", q.And(p), tt.and},
+				{"Or", p.Or(q), tt.or},
+				{"reversed Or", q.Or(p), tt.or},
+				{"Xor", p.Xor(q), tt.xor},
+				{"reversed Xor", q.Xor(p), tt.xorq},
+			} {
+				if got := rhythmString(op.got); got != op.want {
+					t.Errorf("%s = %s, want %s", op.name, got, op.want)
+				}
+			}
+			if rhythmString(p) != tt.p || rhythmString(q) != tt.q {
+				t.Errorf("operands changed to %s and %s", rhythmString(p), rhythmString(q))
+			}
+		})
+	}
+}
+
+func TestCombineStepValues(t *testing.T) {
+
+	p := parseRhythm("x.x.")
+	p.SetAccent(0, AccentStrong)
+	q := parseRhythm("xx")
+	q.SetRatchet(1, 3)
+
+	np := p.Or(q)
+	if got, want := rhythmString(np), "xxxx"; got != want {
+		t.Fatalf("Or = %s, want %s", got, want)
+	}
+
+	accents := []uint8{AccentStrong, AccentNormal, AccentNormal, AccentNormal}
+	ratchets := []uint8{1, 3, 1, 3}
+	for i := range np.Rhythm {
+		if np.Accent(i) != accents[i] {
+			t.Errorf("accent %d = %d, want %d", i, np.Accent(i), accents[i])
+		}
+		if np.Ratchet(i) != ratchets[i] {
+			t.Errorf("ratchet %d = %d, want %d", i, np.Ratchet(i), ratchets[i])
+		}
+	}
+
+	// Rests keep no step values, whichever operand they came from
+	np = p.And(q)
+	if np.Accent(1) != 0 || np.Ratchet(1) != 0 {
+		t.Errorf("rest has accent %d and ratchet %d", np.Accent(1), np.Ratchet(1))
+	}
+}
+
+func TestNot(t *testing.T) {
+
+	tests := []struct{ p, want string }{
+		{"", ""},
+		{"x", "."},
+		{"x.x..", ".x.xx"},
+		{"xxxx", "...."},
+	}
+
+	for _, tt := range tests {
+		p := parseRhythm(tt.p)
+		if len(p.Rhythm) > 0 {
+			p.SetAccent(0, AccentGhost)
+		}
+		np := p.Not()
+		if got := rhythmString(np); got != tt.want {
+			t.Errorf("Not(%s) = %s, want %s", tt.p, got, tt.want)
+		}
+		if np.Accents != nil {
+			t.Errorf("Not(%s) kept accents %v", tt.p, np.Accents)
+		}
+		if got := rhythmString(np.Not()); got != tt.p {
+			t.Errorf("Not(Not(%s)) = %s", tt.p, got)
+		}
+	}
+}
+
+func TestNest(t *testing.T) {
+
+	tests := []struct{ p, q, want string }{
+		{"x.x", "x.", "x...x."},
+		{"x.", "x.x", "x.x..."},
+		{"xx", "x..", "x..x.."},
+		{"..", "xx", "...."},
+		{"x.x", "", ""},
+		{"", "x.", ""},
+	}
+
+	for _, tt := range tests {
+		np := parseRhythm(tt.p).Nest(parseRhythm(tt.q))
+		if got := rhythmString(np); got != tt.want {
+			t.Errorf("%s.Nest(%s) = %s, want %s", tt.p, tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestNestStepValues(t *testing.T) {
+
+	p := parseRhythm("x.x")
+	p.SetAccent(2, AccentGhost)
+	q := parseRhythm("xx")
+	q.SetAccent(1, AccentStrong)
+
+	np := p.Nest(q)
+	if got, want := rhythmString(np), "xx..xx"; got != want {
+		t.Fatalf("Nest = %s, want %s", got, want)
+	}
+
+	// Step values of q are scaled by those of the hit they replace
+	ghostStrong := uint8(int(AccentGhost) * int(AccentStrong) / int(AccentNormal))
+	accents := []uint8{AccentNormal, AccentStrong, 0, 0, AccentGhost, ghostStrong}
+	for i, want := range accents {
+		if got := np.Accent(i); got != want {
+			t.Errorf("accent %d = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestConcat(t *testing.T) {
+
+	tests := []struct{ p, q, want string }{
+		{"x.", "xx.", "x.xx."},
+		{"xx.", "x.", "xx.x."},
+		{"x.", "", "x."},
+		{"", "x.", "x."},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		p, q := parseRhythm(tt.p), parseRhythm(tt.q)
+		if got := rhythmString(p.Concat(q)); got != tt.want {
+			t.Errorf("%s.Concat(%s) = %s, want %s", tt.p, tt.q, got, tt.want)
+		}
+		if rhythmString(p) != tt.p || rhythmString(q) != tt.q {
+			t.Errorf("operands changed to %s and %s", rhythmString(p), rhythmString(q))
+		}
+	}
+
+	// Step values of either side carry over, normal where a side had none
+	p := parseRhythm("x.")
+	p.SetAccent(0, AccentStrong)
+	q := parseRhythm("xx.")
+	q.SetRatchet(1, 2)
+	np := p.Concat(q)
+	accents := []uint8{AccentStrong, 0, AccentNormal, AccentNormal, 0}
+	ratchets := []uint8{1, 0, 1, 2, 0}
+	for i := range np.Rhythm {
+		if np.Accent(i) != accents[i] || np.Ratchet(i) != ratchets[i] {
+			t.Errorf("step %d: accent %d, ratchet %d, want %d, %d", i, np.Accent(i), np.Ratchet(i), accents[i], ratchets[i])
+		}
+	}
+}
+
+func TestReverse(t *testing.T) {
+
+	tests := []struct{ p, want string }{
+		{"x..x.", ".x..x"},
+		{"xx.", ".xx"},
+		{"x", "x"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		p := parseRhythm(tt.p)
+		if got := rhythmString(p.Reverse()); got != tt.want {
+			t.Errorf("%s.Reverse() = %s, want %s", tt.p, got, tt.want)
+		}
+		if rhythmString(p) != tt.p {
+			t.Errorf("operand changed to %s", rhythmString(p))
+		}
+	}
+
+	p := parseRhythm("xx.")
+	p.SetRatchet(0, 3)
+	p.SetChance(1, 40)
+	np := p.Reverse()
+	ratchets := []uint8{0, 1, 3}
+	chances := []uint8{0, 40, 100}
+	for i := range np.Rhythm {
+		if np.Ratchet(i) != ratchets[i] || np.Chance(i) != chances[i] {
+			t.Errorf("step %d: ratchet %d, chance %d, want %d, %d", i, np.Ratchet(i), np.Chance(i), ratchets[i], chances[i])
+		}
+	}
+	if p.Ratchet(0) != 3 {
+		t.Errorf("operand ratchets changed to %v", p.Ratchets)
+	}
+}
+
+func TestRotate(t *testing.T) {
+
+	tests := []struct {
+		p     string
+		steps int
+		want  string
+	}{
+		{"x..x.", 1, "..x.x"},
+		{"x..x.", -1, ".x..x"},
+		{"x..x.", 0, "x..x."},
+		{"x..x.", 5, "x..x."},
+		{"x..x.", 7, ".x.x."},
+		{"x..x.", -6, ".x..x"},
+		{"", 3, ""},
+	}
+
+	for _, tt := range tests {
+		p := parseRhythm(tt.p)
+		if got := rhythmString(p.Rotate(tt.steps)); got != tt.want {
+			t.Errorf("%s.Rotate(%d) = %s, want %s", tt.p, tt.steps, got, tt.want)
+		}
+		if rhythmString(p) != tt.p {
+			t.Errorf("operand changed to %s", rhythmString(p))
+		}
+	}
+
+	// Accents travel with their hits
+	p := parseRhythm("x..x.")
+	p.SetAccent(3, AccentGhost)
+	np := p.Rotate(2)
+	if got, want := rhythmString(np), ".x.x."; got != want {
+		t.Fatalf("Rotate(2) = %s, want %s", got, want)
+	}
+	if np.Accent(1) != AccentGhost || np.Accent(3) != AccentNormal {
+		t.Errorf("accents = %v, want the ghost on step 1", np.Accents)
+	}
+}
+
+func TestStretch(t *testing.T) {
+
+	tests := []struct {
+		p      string
+		length int
+		want   string
+	}{
+		{"x.x.", 8, "x...x..."},
+		{"xx", 3, "xx."},
+		{"x..x", 6, "x...x."},
+		{"x.x.", 4, "x.x."},
+		{"x.x.", 2, "xx"},
+		{"x..x", 3, "x.x"},
+		{"x.x.", 1, "x"},
+		{"x.x.", 0, ""},
+		{"x.x.", -2, ""},
+		{"", 4, "...."},
+	}
+
+	for _, tt := range tests {
+		p := parseRhythm(tt.p)
+		if got := rhythmString(p.Stretch(tt.length)); got != tt.want {
+			t.Errorf("%s.Stretch(%d) = %s, want %s", tt.p, tt.length, got, tt.want)
+		}
+		if rhythmString(p) != tt.p {
+			t.Errorf("operand changed to %s", rhythmString(p))
+		}
+	}
+
+	// Expanding keeps each hit's values; compressing merges them, the
+	// greatest value winning
+	p := parseRhythm("xx")
+	p.SetAccent(0, AccentGhost)
+	p.SetAccent(1, AccentStrong)
+	p.SetRatchet(0, 4)
+
+	np := p.Stretch(4)
+	accents := []uint8{AccentGhost, 0, AccentStrong, 0}
+	ratchets := []uint8{4, 0, 1, 0}
+	for i := range np.Rhythm {
+		if np.Accent(i) != accents[i] || np.Ratchet(i) != ratchets[i] {
+			t.Errorf("expanded step %d: accent %d, ratchet %d, want %d, %d", i, np.Accent(i), np.Ratchet(i), accents[i], ratchets[i])
+		}
+	}
+
+	np = p.Stretch(1)
+	if np.Accent(0) != AccentStrong || np.Ratchet(0) != 4 {
+		t.Errorf("compressed: accent %d, ratchet %d, want %d, 4", np.Accent(0), np.Ratchet(0), AccentStrong)
+	}
+}
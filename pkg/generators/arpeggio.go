@@ -0,0 +1,72 @@
+package generators
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// ArpMode selects the order an arpeggio plays its notes in
+type ArpMode uint8
+
+const (
+	ArpOff ArpMode = iota
+	ArpUp
+	ArpDown
+	ArpUpDown
+	ArpRandom
+	ArpPlayed // in the order the notes were given
+)
+
+// ArpModeNames are the display labels for each ArpMode, in order
+var ArpModeNames = []string{"off", "up", "down", "updn", "rand", "played"}
+
+func (m ArpMode) String() string {
+	if int(m) < len(ArpModeNames) {
+		return ArpModeNames[m]
+	}
+	return "unknown"
+}
+
+// Arpeggio returns one cycle of an arpeggio over the MIDI notes, repeated
+// an octave higher for each of octaves (at least 1). Notes past 127 are
+// dropped. r shuffles the notes in ArpRandom mode.
+func Arpeggio(notes []uint8, mode ArpMode, octaves uint8, r *rand.Rand) []uint8 {
+
+	if octaves < 1 {
+		octaves = 1
+	}
+
+	ordered := append([]uint8{}, notes...)
+	if mode != ArpPlayed {
+		sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+	}
+
+	arp := []uint8{}
+	for o := 0; o < int(octaves); o++ {
+		for _, note := range ordered {
+			if int(note)+12*o <= 127 {
+				arp = append(arp, note+uint8(12*o))
+			}
+		}
+	}
+
+	switch mode {
+	case ArpDown:
+		reverse(arp)
+	case ArpUpDown:
+		// Down again without repeating the top and bottom notes
+		for i := len(arp) - 2; i > 0; i-- {
+			arp = append(arp, arp[i])
+		}
+	case ArpRandom:
+		r.Shuffle(len(arp), func(i, j int) { arp[i], arp[j] = arp[j], arp[i] })
+	}
+
+	return arp
+}
+
+func reverse(notes []uint8) {
+	for i, j := 0, len(notes)-1; i < j; i, j = i+1, j-1 {
+		notes[i], notes[j] = notes[j], notes[i]
+	}
+}
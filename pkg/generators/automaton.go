@@ -0,0 +1,86 @@
+package generators
+
+import "math/rand"
+
+// MaxGeneration is the last generation an Automaton is played from;
+// evolving past it starts again from the first
+const MaxGeneration uint32 = 9999
+
+// Automaton is an elementary cellular automaton. The next state of each
+// cell is the bit of Rule indexed by the cell and its two neighbours, read
+// as a 3 bit number, with the row wrapping at its edges.
+type Automaton struct {
+	Rule uint8
+	Seed int64 // 0 starts from a single live cell in the middle
+}
+
+// First returns the initial row of width cells
+func (a Automaton) First(width int) []uint8 {
+
+	row := make([]uint8, width)
+	if width == 0 {
+		return row
+	}
+
+	if a.Seed == 0 {
+		row[width/2] = 1
+		return row
+	}
+
+	r := rand.New(rand.NewSource(a.Seed))
+	for i := range row {
+		row[i] = uint8(r.Intn(2))
+	}
+
+	return row
+}
+
+// Next returns the generation after row
+func (a Automaton) Next(row []uint8) []uint8 {
+	next := make([]uint8, len(row))
+	for i := range row {
+		left := row[(i+len(row)-1)%len(row)]
+		right := row[(i+1)%len(row)]
+		index := left<<2 | row[i]<<1 | right
+		next[i] = (a.Rule >> index) & 1
+	}
+	return next
+}
+
+// Rows returns count successive generations of width cells, starting from
+// generation start
+func (a Automaton) Rows(width int, start uint32, count int) [][]uint8 {
+
+	row := a.First(width)
+	for i := uint32(0); i < start; i++ {
+		row = a.Next(row)
+	}
+
+	rows := make([][]uint8, count)
+	for i := range rows {
+		rows[i] = row
+		row = a.Next(row)
+	}
+
+	return rows
+}
+
+// AutomatonRhythm gates K steps with one generation of an Automaton
+type AutomatonRhythm struct {
+	Automaton
+	K, Rotation uint8
+	Generation  uint32
+}
+
+func (a AutomatonRhythm) Generate() (*Pattern, error) {
+
+	if a.K == 0 {
+		return nil, ErrNoSteps
+	}
+
+	p := &Pattern{Rhythm: a.Rows(int(a.K), a.Generation, 1)[0]}
+
+	p.rotate(a.Rotation)
+
+	return p, nil
+}
@@ -0,0 +1,45 @@
+package generators
+
+// Layer is a euclidean rhythm that runs at its own rate against the grid,
+// Num steps in every Den beats, so a 5 step layer at 5/4 plays five against
+// four and a 7 step layer at 3/2 cycles every 4 2/3 beats
+type Layer struct {
+	On             bool
+	N, K, Rotation uint8
+	Num, Den       uint8
+}
+
+// NewLayers returns count layers, all off, with a rate of one step per beat
+func NewLayers(count int) []Layer {
+	layers := make([]Layer, count)
+	for i := range layers {
+		layers[i] = Layer{N: 3, K: 8, Num: 1, Den: 1}
+	}
+	return layers
+}
+
+func (l Layer) Generate() (*Pattern, error) {
+	return NewEuclid(l.N, l.K, l.Rotation, 0)
+}
+
+// Beats returns the length of one step of the layer in beats
+func (l Layer) Beats() float64 {
+	if l.Num == 0 {
+		return 1
+	}
+	return float64(l.Den) / float64(l.Num)
+}
+
+// StepAt returns the index of the layer step playing on tick, counting
+// from the start of playback
+func (l Layer) StepAt(tick uint64, ticksPerBeat int) uint64 {
+	if l.Num == 0 || l.Den == 0 {
+		return tick / uint64(ticksPerBeat)
+	}
+	return tick * uint64(l.Num) / (uint64(l.Den) * uint64(ticksPerBeat))
+}
+
+// Starts reports whether a layer step begins on tick
+func (l Layer) Starts(tick uint64, ticksPerBeat int) bool {
+	return tick == 0 || l.StepAt(tick, ticksPerBeat) != l.StepAt(tick-1, ticksPerBeat)
+}
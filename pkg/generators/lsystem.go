@@ -0,0 +1,169 @@
+package generators
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+ * L-system phrases
+ *
+ * An L-system is written as its axiom followed by its rules, separated by
+ * semicolons: "F; F=F+F--F+F". Each iteration rewrites every symbol that
+ * has a rule, all at once. The expanded string is then played:
+ *
+ *   F          note at the current pitch, then the next step
+ *   .          rest, then the next step
+ *   +          up one scale degree
+ *   -          down one scale degree
+ *   [          remember the current pitch
+ *   ]          return to the last remembered pitch
+ *
+ * Any other symbol only takes part in rewriting. Spaces are ignored.
+ */
+
+const (
+	// MaxIterations is the most times an LSystem is rewritten
+	MaxIterations uint8 = 8
+	// MaxExpansion is the most symbols an expansion grows to; rewriting
+	// stops there, so a rule like F=FFF cannot grow without bound
+	MaxExpansion = 4096
+)
+
+// LSystem rewrites its Axiom by its Rules Iterations times, see ParseLSystem
+type LSystem struct {
+	Axiom      string
+	Rules      map[byte]string
+	Iterations uint8
+}
+
+// ParseLSystem reads an axiom and rules written as "axiom; a=rule; b=rule"
+func ParseLSystem(s string) (*LSystem, error) {
+
+	l := &LSystem{Rules: map[byte]string{}}
+
+	pos := 0
+	for i, clause := range strings.Split(s, ";") {
+
+		// Errors point at the first symbol of the clause
+		start := pos + len(clause) - len(strings.TrimLeftFunc(clause, unicode.IsSpace))
+		offset := pos
+		pos += len(clause) + 1
+
+		text := strings.Join(strings.Fields(clause), "")
+		if i == 0 {
+			if text == "" {
+				return nil, &ParseError{Pos: offset, Msg: "empty axiom"}
+			}
+			if err := balanced(clause, offset); err != nil {
+				return nil, err
+			}
+			l.Axiom = text
+			continue
+		}
+		if text == "" {
+			continue
+		}
+
+		parts := strings.SplitN(text, "=", 2)
+		if len(parts) != 2 || len(parts[0]) != 1 {
+			return nil, &ParseError{Pos: start, Msg: "rule must be a single symbol, '=' and its replacement"}
+		}
+		if _, ok := l.Rules[parts[0][0]]; ok {
+			return nil, &ParseError{Pos: start, Msg: "second rule for " + parts[0]}
+		}
+		eq := strings.IndexByte(clause, '=')
+		if err := balanced(clause[eq+1:], offset+eq+1); err != nil {
+			return nil, err
+		}
+		l.Rules[parts[0][0]] = parts[1]
+	}
+
+	return l, nil
+}
+
+// balanced checks that the brackets of s pair up, s starting at offset in
+// the input. Errors point at the bracket left unpaired.
+func balanced(s string, offset int) error {
+	open := []int{}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			open = append(open, offset+i)
+		case ']':
+			if len(open) == 0 {
+				return &ParseError{Pos: offset + i, Msg: "unexpected ']'"}
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return &ParseError{Pos: open[len(open)-1], Msg: "missing ']'"}
+	}
+	return nil
+}
+
+// Expand returns the axiom rewritten Iterations times (at most
+// MaxIterations), cut short at MaxExpansion symbols
+func (l *LSystem) Expand() string {
+
+	iterations := l.Iterations
+	if iterations > MaxIterations {
+		iterations = MaxIterations
+	}
+
+	s := l.Axiom
+	for i := uint8(0); i < iterations; i++ {
+		var b strings.Builder
+		for j := 0; j < len(s) && b.Len() < MaxExpansion; j++ {
+			if rule, ok := l.Rules[s[j]]; ok {
+				b.WriteString(rule)
+			} else {
+				b.WriteByte(s[j])
+			}
+		}
+		s = b.String()
+		if len(s) >= MaxExpansion {
+			s = s[:MaxExpansion]
+			break
+		}
+	}
+
+	return s
+}
+
+// Phrase plays the expansion for up to steps steps. The Keys of the notes
+// are in scale degrees above the first note.
+func (l *LSystem) Phrase(steps int) []Note {
+
+	notes := []Note{}
+	stack := []int{}
+	key, step := 0, 0
+
+	for _, c := range l.Expand() {
+		if step >= steps {
+			break
+		}
+		switch c {
+		case 'F':
+			notes = append(notes, Note{Key: key, Step: step})
+			step++
+		case '.':
+			step++
+		case '+':
+			key++
+		case '-':
+			key--
+		case '[':
+			stack = append(stack, key)
+		case ']':
+			// An expansion cut short can leave a bracket unmatched
+			if len(stack) > 0 {
+				key = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	return notes
+}
@@ -0,0 +1,54 @@
+package generators
+
+import "testing"
+
+func TestParseLSystem(t *testing.T) {
+
+	l, err := ParseLSystem(" F ; F = F[+F]-F ; X=F. ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Axiom != "F" {
+		t.Errorf("axiom = %q, want %q", l.Axiom, "F")
+	}
+	if l.Rules['F'] != "F[+F]-F" || l.Rules['X'] != "F." || len(l.Rules) != 2 {
+		t.Errorf("rules = %q", l.Rules)
+	}
+
+	l.Iterations = 1
+	if got, want := l.Expand(), "F[+F]-F"; got != want {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestParseLSystemErrors(t *testing.T) {
+
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{" ; F=F", 0},
+		{"F]", 1},
+		{"[F", 0},
+		{"F[[F]", 1},
+		{"F; F=F]F", 6},
+		{"F; F = F[+F", 8},
+		{"F;  G=[F]][", 9},
+		{"F;  G=[F][", 9},
+		{"F; FF=F", 3},
+		{"F; F", 3},
+		{"F; F=F; F=FF", 8},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseLSystem(tt.input)
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q: error = %v, want a ParseError", tt.input, err)
+			continue
+		}
+		if perr.Pos != tt.pos {
+			t.Errorf("%q: %v, want position %d", tt.input, perr, tt.pos)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package generators
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/willgarrison/go-noise/pkg/helpers"
+)
+
+// MaxOrder is the longest run of events a Markov model remembers
+const MaxOrder uint8 = 3
+
+// Note is a note of a melody: a pitch, in semitones unless stated
+// otherwise, and the step it starts on
+type Note struct {
+	Key  int
+	Step int
+}
+
+// Event is one move of a melody: the interval in semitones from the
+// previous note and the number of steps until the next
+type Event struct {
+	Interval int8
+	Duration uint8
+}
+
+// Line is a melody to learn from. A looping line wraps from its last
+// event back to its first.
+type Line struct {
+	Events []Event
+	Loop   bool
+}
+
+// NewLine returns the line played by notes, which must be in step order.
+// Only the lowest note of each step is kept. loop is the length in steps of
+// a looping melody, or 0 for one that plays once.
+func NewLine(notes []Note, loop int) Line {
+
+	melody := []Note{}
+	for _, n := range notes {
+		last := len(melody) - 1
+		if last >= 0 && melody[last].Step == n.Step {
+			if n.Key < melody[last].Key {
+				melody[last] = n
+			}
+			continue
+		}
+		melody = append(melody, n)
+	}
+
+	l := Line{Events: make([]Event, len(melody)), Loop: loop > 0}
+	for i, n := range melody {
+		var previous, next Note
+		switch {
+		case i > 0:
+			previous = melody[i-1]
+		case l.Loop:
+			previous = melody[len(melody)-1]
+		default:
+			previous = n
+		}
+		switch {
+		case i < len(melody)-1:
+			next = melody[i+1]
+		case l.Loop:
+			next = Note{Step: melody[0].Step + loop}
+		default:
+			next = Note{Step: n.Step + 1}
+		}
+		l.Events[i] = Event{
+			Interval: int8(helpers.ConstrainInt(n.Key-previous.Key, -127, 127)),
+			Duration: uint8(helpers.ConstrainInt(next.Step-n.Step, 1, 255)),
+		}
+	}
+
+	return l
+}
+
+// Transition is an event seen after a context and how often it was seen
+type Transition struct {
+	Event
+	Count int
+}
+
+// Markov is a Markov chain over the events of melodies. It learns which
+// event follows each run of Order events in its lines.
+type Markov struct {
+	Order       uint8
+	Lines       []Line                  // Kept so the order can change after training
+	Transitions map[string][]Transition // Keyed by context
+}
+
+// Train replaces what m has learnt with lines
+func (m *Markov) Train(lines ...Line) {
+	m.Lines = lines
+	m.build()
+}
+
+// SetOrder sets the order of m (1-3) and relearns its lines
+func (m *Markov) SetOrder(order uint8) {
+	m.Order = uint8(helpers.ConstrainInt(int(order), 1, int(MaxOrder)))
+	m.build()
+}
+
+// Trained reports whether m has learnt any transitions
+func (m *Markov) Trained() bool {
+	return len(m.Transitions) > 0
+}
+
+func (m *Markov) build() {
+
+	if m.Order < 1 {
+		m.Order = 1
+	}
+	order := int(m.Order)
+
+	m.Transitions = map[string][]Transition{}
+	for _, l := range m.Lines {
+		count := len(l.Events) - order
+		if l.Loop {
+			count = len(l.Events)
+		}
+		if len(l.Events) <= order {
+			continue
+		}
+		for i := 0; i < count; i++ {
+			context := make([]Event, order)
+			for j := range context {
+				context[j] = l.Events[(i+j)%len(l.Events)]
+			}
+			m.add(contextKey(context), l.Events[(i+order)%len(l.Events)])
+		}
+	}
+}
+
+func (m *Markov) add(key string, e Event) {
+	transitions := m.Transitions[key]
+	for i := range transitions {
+		if transitions[i].Event == e {
+			transitions[i].Count++
+			return
+		}
+	}
+	m.Transitions[key] = append(transitions, Transition{Event: e, Count: 1})
+}
+
+// Generate returns a melody of length steps, starting on key 0 from the
+// opening of one of the lines m learnt. Randomness (0-1) is the chance that
+// each event is picked from every event m knows rather than from those that
+// followed the current context.
+func (m *Markov) Generate(length int, randomness float64, seed int64) []Note {
+
+	if !m.Trained() {
+		return nil
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	order := int(m.Order)
+
+	starts := [][]Event{}
+	for _, l := range m.Lines {
+		if len(l.Events) > order {
+			starts = append(starts, l.Events[:order])
+		}
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+
+	alphabet := m.alphabet()
+
+	history := append([]Event{}, starts[r.Intn(len(starts))]...)
+
+	notes := []Note{}
+	key, step := -int(history[0].Interval), 0
+	for i := 0; step < length; i++ {
+
+		if i >= len(history) {
+			transitions := m.Transitions[contextKey(history[len(history)-order:])]
+			if len(transitions) == 0 || r.Float64() < randomness {
+				history = append(history, alphabet[r.Intn(len(alphabet))])
+			} else {
+				history = append(history, pick(r, transitions))
+			}
+		}
+
+		e := history[i]
+		key += int(e.Interval)
+		notes = append(notes, Note{Key: key, Step: step})
+		step += int(e.Duration)
+	}
+
+	return notes
+}
+
+// alphabet returns every event m has seen, in a stable order
+func (m *Markov) alphabet() []Event {
+
+	keys := make([]string, 0, len(m.Transitions))
+	for key := range m.Transitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := map[Event]bool{}
+	events := []Event{}
+	for _, key := range keys {
+		for _, t := range m.Transitions[key] {
+			if !seen[t.Event] {
+				seen[t.Event] = true
+				events = append(events, t.Event)
+			}
+		}
+	}
+
+	return events
+}
+
+// pick returns one of transitions, weighted by count
+func pick(r *rand.Rand, transitions []Transition) Event {
+	total := 0
+	for _, t := range transitions {
+		total += t.Count
+	}
+	n := r.Intn(total)
+	for _, t := range transitions {
+		if n < t.Count {
+			return t.Event
+		}
+		n -= t.Count
+	}
+	return transitions[len(transitions)-1].Event
+}
+
+// contextKey joins events into a map key, e.g. "2:1 -3:2"
+func contextKey(events []Event) string {
+	parts := make([]string, len(events))
+	for i, e := range events {
+		parts[i] = strconv.Itoa(int(e.Interval)) + ":" + strconv.Itoa(int(e.Duration))
+	}
+	return strings.Join(parts, " ")
+}
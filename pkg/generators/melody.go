@@ -0,0 +1,22 @@
+package generators
+
+// Melody selects the source of the notes on the grid
+type Melody uint8
+
+const (
+	MelodyNoise Melody = iota
+	MelodyAutomaton
+	MelodyMarkov
+	MelodyTuring
+	MelodyLSystem
+)
+
+// MelodyNames are the display labels for each Melody, in order
+var MelodyNames = []string{"noise", "ca", "markov", "turing", "lsys"}
+
+func (m Melody) String() string {
+	if int(m) < len(MelodyNames) {
+		return MelodyNames[m]
+	}
+	return "unknown"
+}
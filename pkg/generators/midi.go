@@ -0,0 +1,47 @@
+package generators
+
+import (
+	"errors"
+	"sort"
+
+	"gitlab.com/gomidi/midi/reader"
+	"gitlab.com/gomidi/midi/smf"
+)
+
+// ErrTimeFormat is returned for MIDI files timed in SMPTE frames rather than beats
+var ErrTimeFormat = errors.New("generators: midi file must use metric time")
+
+// ReadMIDI returns the notes of the standard MIDI file at path, on every
+// track and channel, with their starts rounded to stepsPerBeat steps per
+// quarter note
+func ReadMIDI(path string, stepsPerBeat int) ([]Note, error) {
+
+	notes := []Note{}
+
+	r := reader.New(
+		reader.NoLogger(),
+		reader.NoteOn(func(p *reader.Position, channel, key, velocity uint8) {
+			notes = append(notes, Note{Key: int(key), Step: int(p.AbsoluteTicks)})
+		}),
+	)
+
+	if err := reader.ReadSMFFile(r, path); err != nil {
+		return nil, err
+	}
+
+	ticks, ok := r.Header().TimeFormat.(smf.MetricTicks)
+	if !ok {
+		return nil, ErrTimeFormat
+	}
+
+	resolution := int(ticks.Resolution())
+	for i := range notes {
+		notes[i].Step = (notes[i].Step*stepsPerBeat + resolution/2) / resolution
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Step < notes[j].Step
+	})
+
+	return notes, nil
+}
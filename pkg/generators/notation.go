@@ -0,0 +1,316 @@
+package generators
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Rhythm notation
+ *
+ *   x          hit
+ *   X          accented hit
+ *   o          ghost hit
+ *   x?n        hit with an n percent chance of firing (also X?n, o?n)
+ *   x:n        hit repeated n times (1-4) within its step
+ *   . or -     rest
+ *   E(n,k)     euclidean rhythm of n hits over k steps
+ *   E(n,k,r)   the same rotated left by r steps
+ *   ( ... )    group
+ *   *n         repeat the preceding step, group or euclidean rhythm n times
+ *
+ * Spaces and bar lines (|) are ignored, so "x..x ..x. | E(3,8)*2" is valid.
+ */
+
+// MaxSteps is the longest pattern the grid can play, one step per beat index
+const MaxSteps = 256
+
+// ParseError reports where and why a rhythm could not be parsed
+type ParseError struct {
+	Pos int // byte offset into the input
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("generators: parse error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Notation parses its Text as a rhythm, see Parse
+type Notation struct {
+	Text string
+}
+
+func (n Notation) Generate() (*Pattern, error) {
+	return Parse(n.Text)
+}
+
+// Parse turns rhythm notation into a Pattern. Accents, chances and ratchets
+// are only set when the notation uses them.
+func Parse(s string) (*Pattern, error) {
+
+	p := &parser{input: s}
+
+	pattern, err := p.sequence()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	if pattern.Len() == 0 {
+		return nil, p.errorf("empty pattern")
+	}
+
+	pattern.compact()
+
+	return pattern, nil
+}
+
+// String formats p in rhythm notation, step by step
+func (p *Pattern) String() string {
+	var b strings.Builder
+	for i, v := range p.Rhythm {
+		switch {
+		case v == 0:
+			b.WriteByte('.')
+			continue
+		case p.Accent(i) >= AccentStrong:
+			b.WriteByte('X')
+		case p.Accent(i) <= AccentGhost:
+			b.WriteByte('o')
+		default:
+			b.WriteByte('x')
+		}
+		if c := p.Chance(i); c != chanceLane.normal {
+			fmt.Fprintf(&b, "?%d", c)
+		}
+		if r := p.Ratchet(i); r != ratchetLane.normal {
+			fmt.Fprintf(&b, ":%d", r)
+		}
+	}
+	return b.String()
+}
+
+type parser struct {
+	input string
+	pos   int
+	depth int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &ParseError{Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// sequence parses items until the end of input or a closing parenthesis
+func (p *parser) sequence() (*Pattern, error) {
+
+	pattern := new(Pattern)
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) || p.input[p.pos] == ')' {
+			return pattern, nil
+		}
+
+		item, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		if pattern.Len()+item.Len() > MaxSteps {
+			return nil, p.errorf("pattern longer than %d steps", MaxSteps)
+		}
+		pattern = pattern.Concat(item)
+	}
+}
+
+// item parses a step, group or euclidean rhythm with an optional repeat
+func (p *parser) item() (pattern *Pattern, err error) {
+
+	switch c := p.input[p.pos]; c {
+	case 'x', 'X', 'o':
+		p.pos++
+		pattern, err = p.hit(c)
+	case '.', '-':
+		p.pos++
+		pattern = NewPattern([]uint8{0})
+	case 'E', 'e':
+		pattern, err = p.euclid()
+	case '(':
+		pattern, err = p.group()
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '*' {
+		p.pos++
+		p.skipSpace()
+		start := p.pos
+		count, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			p.pos = start
+			return nil, p.errorf("repeat count must be at least 1")
+		}
+		if pattern.Len()*count > MaxSteps {
+			p.pos = start
+			return nil, p.errorf("pattern longer than %d steps", MaxSteps)
+		}
+		unit := pattern
+		for i := 1; i < count; i++ {
+			pattern = pattern.Concat(unit)
+		}
+	}
+
+	return pattern, nil
+}
+
+// hit parses the options of a hit: ?n for a chance in percent, :n for a ratchet
+func (p *parser) hit(c byte) (*Pattern, error) {
+
+	pattern := NewPattern([]uint8{1})
+	switch c {
+	case 'X':
+		pattern.SetAccent(0, AccentStrong)
+	case 'o':
+		pattern.SetAccent(0, AccentGhost)
+	}
+
+	for p.pos < len(p.input) && (p.input[p.pos] == '?' || p.input[p.pos] == ':') {
+		option := p.input[p.pos]
+		p.pos++
+		start := p.pos
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case option == '?' && n <= 100:
+			pattern.SetChance(0, uint8(n))
+		case option == ':' && n >= 1 && n <= int(MaxRatchet):
+			pattern.SetRatchet(0, uint8(n))
+		case option == '?':
+			return nil, &ParseError{Pos: start, Msg: "chance must be 0 to 100"}
+		default:
+			return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("ratchet must be 1 to %d", MaxRatchet)}
+		}
+	}
+
+	return pattern, nil
+}
+
+func (p *parser) group() (*Pattern, error) {
+
+	open := p.pos
+	p.pos++
+	p.depth++
+	if p.depth > 32 {
+		return nil, p.errorf("groups nested too deeply")
+	}
+
+	pattern, err := p.sequence()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.input) {
+		return nil, &ParseError{Pos: open, Msg: "unclosed group"}
+	}
+	p.pos++ // skip ')'
+	p.depth--
+
+	if pattern.Len() == 0 {
+		return nil, &ParseError{Pos: open, Msg: "empty group"}
+	}
+
+	return pattern, nil
+}
+
+// euclid parses E(n,k) or E(n,k,r)
+func (p *parser) euclid() (*Pattern, error) {
+
+	start := p.pos
+	p.pos++
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+
+	var args []int
+	for {
+		p.skipSpace()
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+		p.skipSpace()
+		if p.pos < len(p.input) && p.input[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	if len(args) < 2 || len(args) > 3 {
+		return nil, &ParseError{Pos: start, Msg: "euclidean rhythm takes 2 or 3 arguments"}
+	}
+	for _, a := range args {
+		if a > MaxSteps-1 {
+			return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("euclidean argument %d too large", a)}
+		}
+	}
+	args = append(args, 0)
+
+	pattern, err := NewEuclid(uint8(args[0]), uint8(args[1]), uint8(args[2]), 0)
+	if err != nil {
+		return nil, &ParseError{Pos: start, Msg: strings.TrimPrefix(err.Error(), "generators: ")}
+	}
+
+	return pattern, nil
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return p.errorf("expected %q, found end of input", c)
+	}
+	if p.input[p.pos] != c {
+		return p.errorf("expected %q, found %q", c, p.input[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) number() (int, error) {
+	start := p.pos
+	n := 0
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		n = n*10 + int(p.input[p.pos]-'0')
+		if n > 9999 {
+			return 0, &ParseError{Pos: start, Msg: "number too large"}
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, p.errorf("expected a number")
+	}
+	return n, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '|':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package generators
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  string // in the notation of Pattern.String
+	}{
+		{"x..x", "x..x"},
+		{"x-x-", "x.x."},
+		{"x..x ..x. | E(3,8)*2", "x..x..x.x..x..x.x..x..x."},
+		{"E(3,8)", "x..x..x."},
+		{"E(3,8,2)", ".x..x.x."},
+		{"e( 3 , 8 )", "x..x..x."},
+		{"E(0,4)", "...."},
+		{"(x.)*3", "x.x.x."},
+		{"x*3.", "xxx."},
+		{"x * 2", "xx"},
+		{"((x.)*2 X)*2", "x.x.Xx.x.X"},
+		{"X o x", "Xox"},
+		{"x?50", "x?50"},
+		{"x:3", "x:3"},
+		{"X?25:2", "X?25:2"},
+		{"o:4.", "o:4."},
+		{"x?0", "x?0"},
+		{"x?100 x:1", "xx"},
+		{"(X?50:2.)*2", "X?50:2.X?50:2."},
+		{"x*256", strings.Repeat("x", 256)},
+		{"(x.)*128", strings.Repeat("x.", 128)},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got := p.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseStepValues(t *testing.T) {
+
+	p, err := Parse("X.o?30x:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accents := []uint8{AccentStrong, 0, AccentGhost, AccentNormal}
+	chances := []uint8{100, 0, 30, 100}
+	ratchets := []uint8{1, 0, 1, 3}
+	for i := range p.Rhythm {
+		if p.Accent(i) != accents[i] || p.Chance(i) != chances[i] || p.Ratchet(i) != ratchets[i] {
+			t.Errorf("step %d: accent %d, chance %d, ratchet %d, want %d, %d, %d",
+				i, p.Accent(i), p.Chance(i), p.Ratchet(i), accents[i], chances[i], ratchets[i])
+		}
+	}
+
+	// Lanes the notation does not use stay unset
+	p, err = Parse("x.xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Accents != nil || p.Chances != nil || p.Ratchets != nil {
+		t.Errorf("plain rhythm has lanes %v %v %v", p.Accents, p.Chances, p.Ratchets)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 0},
+		{"   ", 3},
+		{"x.y", 2},
+		{"x)", 1},
+		{"(x.", 0},
+		{"x (x", 2},
+		{"()", 0},
+		{"x*0", 2},
+		{"x*", 2},
+		{"x?101", 2},
+		{"x:5", 2},
+		{"x:0", 2},
+		{"x?99999", 2},
+		{"E(3)", 0},
+		{"..E(1,2,3,4)", 2},
+		{"E(5,3)", 0},
+		{"E(3,0)", 0},
+		{"E(3,8", 5},
+		{"E[3,8]", 1},
+		{"E(3,x)", 4},
+		{"E(256,300)", 0},
+		{"x*257", 2},
+		{"E(3,8)*33", 7},
+		{"x*200 x*57", 10},
+		{strings.Repeat("(", 33) + "x" + strings.Repeat(")", 33), 33},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("Parse(%q) error = %v, want a ParseError", tt.input, err)
+			continue
+		}
+		if perr.Pos != tt.pos {
+			t.Errorf("Parse(%q): %v, want position %d", tt.input, perr, tt.pos)
+		}
+	}
+}
+
+func TestParseStringRoundTrip(t *testing.T) {
+
+	patterns := []*Pattern{}
+	for _, input := range []string{"x..x..x.", "X?50:2.o:4x?0", "E(5,16,3)", "(Xo.x:2)*4"} {
+		p, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		patterns = append(patterns, p)
+	}
+
+	// Patterns built by the algebra round trip as well
+	p := parseRhythm("x.xx.x")
+	p.SetAccent(0, AccentStrong)
+	p.SetChance(2, 75)
+	p.SetRatchet(3, 2)
+	patterns = append(patterns, p, p.Nest(parseRhythm("x.")))
+
+	for _, p := range patterns {
+		s := p.String()
+		q, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+			continue
+		}
+		if q.String() != s {
+			t.Errorf("Parse(%q).String() = %q", s, q.String())
+		}
+		for i := range p.Rhythm {
+			if p.Accent(i) != q.Accent(i) || p.Chance(i) != q.Chance(i) || p.Ratchet(i) != q.Ratchet(i) {
+				t.Errorf("%q step %d: step values changed", s, i)
+			}
+		}
+	}
+}
+
+func TestNotationGenerate(t *testing.T) {
+	p, err := Notation{Text: "E(3,8)"}.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.String(), "x..x..x."; got != want {
+		t.Errorf("Generate = %s, want %s", got, want)
+	}
+	if _, err := (Notation{Text: "x?"}).Generate(); err == nil {
+		t.Error("Generate of malformed notation succeeded")
+	}
+}
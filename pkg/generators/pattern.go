@@ -1,24 +1,67 @@
 package generators
 
-import "github.com/willgarrison/go-noise/pkg/helpers"
+import (
+	"errors"
+
+	"github.com/willgarrison/go-noise/pkg/helpers"
+)
+
+// Algorithm selects how pulses are spread over the steps of a euclidean rhythm
+type Algorithm uint8
+
+const (
+	// Bjorklund produces the canonical rhythms catalogued by Toussaint
+	Bjorklund Algorithm = iota
+	// Bresenham spaces pulses along a line; same onsets, different rotation
+	Bresenham
+)
+
+// AlgorithmNames are the display labels for each Algorithm, in order
+var AlgorithmNames = []string{"bjork", "bres"}
+
+func (a Algorithm) String() string {
+	if int(a) < len(AlgorithmNames) {
+		return AlgorithmNames[a]
+	}
+	return "unknown"
+}
+
+var (
+	// ErrNoSteps is returned when a pattern has no steps (k = 0)
+	ErrNoSteps = errors.New("generators: k must be greater than 0")
+	// ErrTooManyPulses is returned when there are more pulses than steps (n > k)
+	ErrTooManyPulses = errors.New("generators: n must not be greater than k")
+)
 
 type Pattern struct {
-	Rhythm []uint8
+	Rhythm   []uint8
+	Accents  []uint8 // Accent level per step, nil when every hit is normal
+	Chances  []uint8 // Chance in percent that a step fires, nil when every hit always fires
+	Ratchets []uint8 // Repeats within a step, nil when every hit plays once
 }
 
+// NewEuclid returns the euclidean rhythm of n pulses over k steps using the
+// Bjorklund algorithm, rotated left by rotation steps
 func NewEuclid(n, k, rotation uint8, groove float64) (*Pattern, error) {
+	return NewEuclidAlgorithm(Bjorklund, n, k, rotation, groove)
+}
 
-	p := new(Pattern)
+// NewEuclidAlgorithm is NewEuclid with a choice of algorithm
+func NewEuclidAlgorithm(a Algorithm, n, k, rotation uint8, groove float64) (*Pattern, error) {
 
-	// flip n and k if n is greater than k
+	if k == 0 {
+		return nil, ErrNoSteps
+	}
 	if n > k {
-		n, k = k, n
+		return nil, ErrTooManyPulses
 	}
 
-	p.createEuclidPattern(n, k)
+	p := new(Pattern)
+
+	p.createEuclidPattern(a, n, k)
 
 	if groove != 0 {
-		p.setGroove(n, k, groove)
+		p.setGroove(a, n, k, groove)
 	}
 
 	if rotation != 0 {
@@ -28,8 +71,72 @@ func NewEuclid(n, k, rotation uint8, groove float64) (*Pattern, error) {
 	return p, nil
 }
 
-// createPattern creates a new rhythmic pattern using Bresenham’s line algorithm
-func (p *Pattern) createEuclidPattern(n, k uint8) {
+func (p *Pattern) createEuclidPattern(a Algorithm, n, k uint8) {
+	switch a {
+	case Bresenham:
+		p.createBresenhamPattern(n, k)
+	default:
+		p.createBjorklundPattern(n, k)
+	}
+}
+
+// createBjorklundPattern creates a new rhythmic pattern using Bjorklund's algorithm:
+// start with n sequences of [1] and k-n of [0], then keep appending the
+// remainder sequences to the leading ones until at most one remainder is left
+func (p *Pattern) createBjorklundPattern(n, k uint8) {
+
+	p.Rhythm = make([]uint8, k)
+
+	if n == 0 {
+		return
+	}
+
+	front := make([][]uint8, n)
+	for i := range front {
+		front[i] = []uint8{1}
+	}
+	back := make([][]uint8, k-n)
+	for i := range back {
+		back[i] = []uint8{0}
+	}
+
+	for len(back) > 0 {
+
+		pairs := len(front)
+		if len(back) < pairs {
+			pairs = len(back)
+		}
+
+		joined := make([][]uint8, pairs)
+		for i := range joined {
+			joined[i] = append(front[i], back[i]...)
+		}
+
+		if len(front) > pairs {
+			back = front[pairs:]
+		} else {
+			back = back[pairs:]
+		}
+		front = joined
+
+		if len(back) <= 1 {
+			break
+		}
+	}
+
+	p.Rhythm = p.Rhythm[:0]
+	for _, seq := range append(front, back...) {
+		p.Rhythm = append(p.Rhythm, seq...)
+	}
+}
+
+// createBresenhamPattern creates a new rhythmic pattern using Bresenham’s line algorithm
+func (p *Pattern) createBresenhamPattern(n, k uint8) {
+
+	if n == 0 {
+		p.Rhythm = make([]uint8, k)
+		return
+	}
 
 	p.Rhythm = []uint8{}
 
@@ -70,20 +177,44 @@ func (p *Pattern) setRotate(rotation int) {
 	np = append(np, p.Rhythm[:offset]...)
 
 	p.Rhythm = np
+
+	for _, l := range stepLanes {
+		if values := l.of(p); *values != nil {
+			nv := []uint8{}
+			nv = append(nv, (*values)[offset:]...)
+			nv = append(nv, (*values)[:offset]...)
+			*values = nv
+		}
+	}
 }
 
-func (p *Pattern) setGroove(n, k uint8, groove float64) {
+func (p *Pattern) setGroove(a Algorithm, n, k uint8, groove float64) {
 
 	tmpRhythm := make([]uint8, len(p.Rhythm))
 	copy(tmpRhythm, p.Rhythm)
 
+	// Step values travel with the hits they belong to
+	tmp := &Pattern{Rhythm: tmpRhythm}
+	for _, l := range stepLanes {
+		if values := *l.of(p); values != nil {
+			*l.of(tmp) = append([]uint8{}, values...)
+		}
+	}
+	move := func(from, to int) {
+		for _, l := range stepLanes {
+			if values := *l.of(tmp); values != nil {
+				values[to] = (*l.of(p))[from]
+			}
+		}
+	}
+
 	gn := (uint8(helpers.ReRange(groove, 0, 100, 0, float64(k))) + n)
 	if gn > k {
 		gn = k
 	}
 
 	groovePattern := new(Pattern)
-	groovePattern.createEuclidPattern(gn, k)
+	groovePattern.createEuclidPattern(a, gn, k)
 
 	midPointIndex := int(k / 2)
 
@@ -93,6 +224,11 @@ func (p *Pattern) setGroove(n, k uint8, groove float64) {
 		if p.Rhythm[i] == 1 && i != midPointIndex {
 
 			tmpRhythm[i] = 0
+			for _, l := range stepLanes {
+				if values := *l.of(tmp); values != nil {
+					values[i] = 0
+				}
+			}
 
 			groovePatternIndex := i
 			distance := 1
@@ -106,11 +242,13 @@ func (p *Pattern) setGroove(n, k uint8, groove float64) {
 
 				if groovePatternIndex < 0 {
 					tmpRhythm[i] = 1
+					move(i, i)
 					break
 				}
 
 				if groovePattern.Rhythm[groovePatternIndex] == 1 && tmpRhythm[groovePatternIndex] != 1 {
 					tmpRhythm[groovePatternIndex] = 1
+					move(i, groovePatternIndex)
 					found = true
 				}
 
@@ -122,5 +260,5 @@ func (p *Pattern) setGroove(n, k uint8, groove float64) {
 		i++
 	}
 
-	p.Rhythm = tmpRhythm
+	*p = *tmp
 }
@@ -0,0 +1,112 @@
+package generators
+
+import (
+	"strings"
+	"testing"
+)
+
+// toussaint lists the euclidean rhythms catalogued in Toussaint, "The
+// Euclidean Algorithm Generates Traditional Musical Rhythms" (2005), with
+// x for a pulse and . for a rest
+var toussaint = []struct {
+	n, k   uint8
+	rhythm string
+}{
+	{2, 5, "x.x.."},
+	{3, 4, "x.xx"},
+	{3, 5, "x.x.x"},
+	{3, 7, "x.x.x.."},
+	{3, 8, "x..x..x."},
+	{4, 7, "x.x.x.x"},
+	{4, 9, "x.x.x.x.."},
+	{4, 11, "x..x..x..x."},
+	{5, 6, "x.xxxx"},
+	{5, 7, "x.xx.xx"},
+	{5, 8, "x.xx.xx."},
+	{5, 9, "x.x.x.x.x"},
+	{5, 11, "x.x.x.x.x.."},
+	{5, 12, "x..x.x..x.x."},
+	{5, 16, "x..x..x..x..x..."},
+	{7, 8, "x.xxxxxx"},
+	{7, 12, "x.xx.x.xx.x."},
+	{7, 16, "x..x.x.x..x.x.x."},
+	{9, 16, "x.xx.x.x.xx.x.x."},
+	{11, 24, "x..x.x.x.x.x..x.x.x.x.x."},
+	{13, 24, "x.xx.x.x.x.x.xx.x.x.x.x."},
+}
+
+func rhythmString(p *Pattern) string {
+	var b strings.Builder
+	for _, step := range p.Rhythm {
+		if step == 1 {
+			b.WriteByte('x')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+func TestEuclidToussaint(t *testing.T) {
+	for _, tt := range toussaint {
+		p, err := NewEuclid(tt.n, tt.k, 0, 0)
+		if err != nil {
+			t.Fatalf("E(%d,%d): %v", tt.n, tt.k, err)
+		}
+		if got := rhythmString(p); got != tt.rhythm {
+			t.Errorf("E(%d,%d) = %s, want %s", tt.n, tt.k, got, tt.rhythm)
+		}
+	}
+}
+
+func TestEuclidBresenhamIsRotation(t *testing.T) {
+	for _, tt := range toussaint {
+		p, err := NewEuclidAlgorithm(Bresenham, tt.n, tt.k, 0, 0)
+		if err != nil {
+			t.Fatalf("E(%d,%d): %v", tt.n, tt.k, err)
+		}
+		got := rhythmString(p)
+		if len(got) != len(tt.rhythm) || !strings.Contains(tt.rhythm+tt.rhythm, got) {
+			t.Errorf("E(%d,%d) = %s, not a rotation of %s", tt.n, tt.k, got, tt.rhythm)
+		}
+	}
+}
+
+func TestEuclidRotation(t *testing.T) {
+	p, err := NewEuclid(3, 8, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rhythmString(p), ".x..x.x."; got != want {
+		t.Errorf("E(3,8) rotated 2 = %s, want %s", got, want)
+	}
+}
+
+func TestEuclidEdges(t *testing.T) {
+
+	tests := []struct {
+		n, k   uint8
+		rhythm string
+		err    error
+	}{
+		{0, 4, "....", nil},
+		{4, 4, "xxxx", nil},
+		{1, 1, "x", nil},
+		{5, 4, "", ErrTooManyPulses},
+		{1, 0, "", ErrNoSteps},
+		{0, 0, "", ErrNoSteps},
+	}
+
+	for _, a := range []Algorithm{Bjorklund, Bresenham} {
+		for _, tt := range tests {
+			p, err := NewEuclidAlgorithm(a, tt.n, tt.k, 0, 0)
+			if err != tt.err {
+				t.Errorf("%v E(%d,%d) error = %v, want %v", a, tt.n, tt.k, err, tt.err)
+				continue
+			}
+			if err == nil && rhythmString(p) != tt.rhythm {
+				t.Errorf("%v E(%d,%d) = %s, want %s", a, tt.n, tt.k, rhythmString(p), tt.rhythm)
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package generators
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
+)
+
+// RhythmGenerator produces a rhythmic pattern from its parameters
+type RhythmGenerator interface {
+	Generate() (*Pattern, error)
+}
+
+// Kind identifies a RhythmGenerator implementation on the control board
+type Kind uint8
+
+const (
+	KindEuclid Kind = iota
+	KindDensity
+	KindTimeline
+	KindFibonacci
+	KindNoise
+	KindNotation
+	KindAutomaton
+	KindTuring
+)
+
+// KindNames are the display labels for each Kind, in order
+var KindNames = []string{"euclid", "dens", "clave", "fib", "noise", "text", "ca", "turing"}
+
+func (k Kind) String() string {
+	if int(k) < len(KindNames) {
+		return KindNames[k]
+	}
+	return "unknown"
+}
+
+// Euclid spreads N pulses as evenly as possible over K steps
+type Euclid struct {
+	N, K, Rotation uint8
+	Groove         float64
+	Algorithm      Algorithm
+}
+
+func (e Euclid) Generate() (*Pattern, error) {
+	return NewEuclidAlgorithm(e.Algorithm, e.N, e.K, e.Rotation, e.Groove)
+}
+
+// Density turns each of K steps on with probability Density (0-1).
+// The same Seed always yields the same pattern.
+type Density struct {
+	K, Rotation uint8
+	Density     float64
+	Seed        int64
+}
+
+func (d Density) Generate() (*Pattern, error) {
+
+	if d.K == 0 {
+		return nil, ErrNoSteps
+	}
+
+	r := rand.New(rand.NewSource(d.Seed))
+
+	p := new(Pattern)
+	p.Rhythm = make([]uint8, d.K)
+	for i := range p.Rhythm {
+		if r.Float64() < d.Density {
+			p.Rhythm[i] = 1
+		}
+	}
+
+	p.rotate(d.Rotation)
+
+	return p, nil
+}
+
+// Timelines are traditional clave and bell patterns, 'x' marking an onset
+var Timelines = []string{
+	"x..x..x.",         // tresillo
+	"x.xx.xx.",         // cinquillo
+	"x..x..x...x.x...", // son clave 3-2
+	"..x.x...x..x..x.", // son clave 2-3
+	"x..x...x..x.x...", // rumba clave 3-2
+	"x..x..x...x..x..", // bossa nova
+	"x.x.xx.x.x.x",     // standard bell
+	"x..x..x...x...x.", // gahu
+	"x...x.x...x.x...", // shiko
+	"x..x..x...xx....", // soukous
+}
+
+// TimelineNames are the display labels for each of Timelines, in order
+var TimelineNames = []string{"tres", "cinq", "son32", "son23", "rumba", "bossa", "bell", "gahu", "shiko", "souk"}
+
+// Timeline plays one of Timelines
+type Timeline struct {
+	Index, Rotation uint8
+}
+
+func (t Timeline) Generate() (*Pattern, error) {
+
+	timeline := Timelines[int(t.Index)%len(Timelines)]
+
+	p := new(Pattern)
+	p.Rhythm = make([]uint8, len(timeline))
+	for i := range timeline {
+		if timeline[i] == 'x' {
+			p.Rhythm[i] = 1
+		}
+	}
+
+	p.rotate(t.Rotation)
+
+	return p, nil
+}
+
+// Fibonacci places onsets over K steps following the Fibonacci word, so
+// the ratio of steps to onsets approaches the golden ratio
+type Fibonacci struct {
+	K, Rotation uint8
+}
+
+func (f Fibonacci) Generate() (*Pattern, error) {
+
+	if f.K == 0 {
+		return nil, ErrNoSteps
+	}
+
+	invPhi := 2 / (1 + math.Sqrt(5))
+
+	p := new(Pattern)
+	p.Rhythm = make([]uint8, f.K)
+	for i := range p.Rhythm {
+		if math.Floor(float64(i)*invPhi) != math.Floor(float64(i-1)*invPhi) {
+			p.Rhythm[i] = 1
+		}
+	}
+
+	p.rotate(f.Rotation)
+
+	return p, nil
+}
+
+// NoiseThreshold turns a step on when normalized fbm noise at that step
+// exceeds Threshold (-1 to 1)
+type NoiseThreshold struct {
+	K, Rotation uint8
+	Threshold   float64
+	Seed        int64
+	Offset      uint32
+	Frequency   float64
+	Lacunarity  float64
+	Gain        float64
+	Octaves     uint8
+}
+
+// noiseThresholdY keeps the rhythm row clear of the note field
+const noiseThresholdY = 4000.5
+
+func (n NoiseThreshold) Generate() (*Pattern, error) {
+
+	if n.K == 0 {
+		return nil, ErrNoSteps
+	}
+
+	g := simplexnoise.New(n.Seed)
+
+	p := new(Pattern)
+	p.Rhythm = make([]uint8, n.K)
+	for i := range p.Rhythm {
+		x := float32(n.Offset) + float32(i)
+		v := g.FbmNormalized(x, noiseThresholdY, float32(n.Frequency), float32(n.Lacunarity), float32(n.Gain), int(n.Octaves))
+		if float64(v) > n.Threshold {
+			p.Rhythm[i] = 1
+		}
+	}
+
+	p.rotate(n.Rotation)
+
+	return p, nil
+}
+
+// Accented accents the hits of another generator with a euclidean layer of
+// N accents spread over its hits, rotated by Rotation hits, see AccentWith
+type Accented struct {
+	Generator   RhythmGenerator
+	N, Rotation uint8
+	Level       uint8
+}
+
+func (a Accented) Generate() (*Pattern, error) {
+
+	p, err := a.Generator.Generate()
+	if err != nil || a.N == 0 || p.Hits() == 0 {
+		return p, err
+	}
+
+	hits := p.Hits()
+	if hits > MaxSteps-1 {
+		hits = MaxSteps - 1
+	}
+	n := a.N
+	if int(n) > hits {
+		n = uint8(hits)
+	}
+
+	layer, err := NewEuclid(n, uint8(hits), a.Rotation, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.AccentWith(layer, a.Level), nil
+}
+
+// Varied scales the chance of every hit of another generator by Chance
+// (percent) and ratchets the hits that play once Ratchet times
+type Varied struct {
+	Generator       RhythmGenerator
+	Chance, Ratchet uint8
+}
+
+func (v Varied) Generate() (*Pattern, error) {
+
+	p, err := v.Generator.Generate()
+	if err != nil {
+		return p, err
+	}
+
+	for i, hit := range p.Rhythm {
+		if hit == 0 {
+			continue
+		}
+		if v.Chance < chanceLane.normal {
+			p.SetChance(i, chanceLane.scale(p.Chance(i), v.Chance))
+		}
+		if v.Ratchet > 1 && p.Ratchet(i) == 1 {
+			p.SetRatchet(i, v.Ratchet)
+		}
+	}
+
+	return p, nil
+}
+
+// rotate shifts the rhythm left by rotation steps
+func (p *Pattern) rotate(rotation uint8) {
+	if rotation != 0 && len(p.Rhythm) > 0 {
+		p.setRotate(int(rotation))
+	}
+}
@@ -0,0 +1,122 @@
+package generators
+
+// Per-step values that travel with the hits of a pattern. Each is nil on a
+// pattern where every hit has the normal value, and 0 on rests otherwise.
+
+// Accent levels for Pattern.Accents, on the MIDI velocity scale
+const (
+	AccentGhost  uint8 = 50
+	AccentNormal uint8 = 100
+	AccentStrong uint8 = 127
+)
+
+// MaxRatchet is the most times a hit can repeat within its step
+const MaxRatchet uint8 = 4
+
+type stepLane struct {
+	of     func(p *Pattern) *[]uint8
+	normal uint8
+	max    uint8
+}
+
+var (
+	accentLane  = stepLane{func(p *Pattern) *[]uint8 { return &p.Accents }, AccentNormal, 127}
+	chanceLane  = stepLane{func(p *Pattern) *[]uint8 { return &p.Chances }, 100, 100}
+	ratchetLane = stepLane{func(p *Pattern) *[]uint8 { return &p.Ratchets }, 1, MaxRatchet}
+	stepLanes   = []stepLane{accentLane, chanceLane, ratchetLane}
+)
+
+// value returns the lane value of step i of p
+func (l stepLane) value(p *Pattern, i int) uint8 {
+	values := *l.of(p)
+	if values == nil {
+		if p.Rhythm[i] == 1 {
+			return l.normal
+		}
+		return 0
+	}
+	return values[i]
+}
+
+// values returns the lane value of every step of p
+func (l stepLane) values(p *Pattern) []uint8 {
+	values := make([]uint8, len(p.Rhythm))
+	for i := range p.Rhythm {
+		values[i] = l.value(p, i)
+	}
+	return values
+}
+
+// set sets the lane value of step i of p, giving p the lane if it had none
+func (l stepLane) set(p *Pattern, i int, v uint8) {
+	values := l.of(p)
+	if *values == nil {
+		*values = l.values(p)
+	}
+	if v > l.max {
+		v = l.max
+	}
+	(*values)[i] = v
+}
+
+// scale returns a scaled by b, where the normal value leaves a unchanged
+func (l stepLane) scale(a, b uint8) uint8 {
+	v := int(a) * int(b) / int(l.normal)
+	if v > int(l.max) {
+		v = int(l.max)
+	}
+	return uint8(v)
+}
+
+// Accent returns the accent level of step i, AccentNormal for hits when p has no accents
+func (p *Pattern) Accent(i int) uint8 {
+	return accentLane.value(p, i)
+}
+
+// Chance returns the chance in percent that step i fires, 100 for hits when p has no chances
+func (p *Pattern) Chance(i int) uint8 {
+	return chanceLane.value(p, i)
+}
+
+// Ratchet returns how many times step i repeats within the step, 1 for hits when p has no ratchets
+func (p *Pattern) Ratchet(i int) uint8 {
+	return ratchetLane.value(p, i)
+}
+
+// SetAccent sets the accent level of step i
+func (p *Pattern) SetAccent(i int, level uint8) {
+	accentLane.set(p, i, level)
+}
+
+// SetChance sets the chance in percent (0-100) that step i fires
+func (p *Pattern) SetChance(i int, chance uint8) {
+	chanceLane.set(p, i, chance)
+}
+
+// SetRatchet sets how many times (1-4) step i repeats within the step
+func (p *Pattern) SetRatchet(i int, count uint8) {
+	if count < 1 {
+		count = 1
+	}
+	ratchetLane.set(p, i, count)
+}
+
+// compact drops the lanes in which every hit has the normal value
+func (p *Pattern) compact() {
+	for _, l := range stepLanes {
+		values := l.of(p)
+		if *values == nil {
+			continue
+		}
+		normal := true
+		for i, v := range p.Rhythm {
+			if v == 1 && (*values)[i] != l.normal {
+				normal = false
+				break
+			}
+		}
+		if normal {
+			*values = nil
+		}
+	}
+}
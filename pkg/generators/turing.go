@@ -0,0 +1,97 @@
+package generators
+
+import "math/rand"
+
+// Turing is a looping shift register of random bits, after the Turing
+// Machine modular sequencer. The first K bits of Register form the loop:
+// bit i gates step i, and the 8 bits from step i on, read as a number, are
+// its pitch. Each clock the loop shifts one step and the bit wrapping from
+// its start to its end flips with a chance of Flip percent, so a low Flip
+// repeats the loop with slow variations and a Flip of 100 inverts it every
+// cycle. A Locked register shifts without flipping, repeating exactly.
+type Turing struct {
+	Register    uint32
+	K, Rotation uint8
+	Flip        float64
+	Locked      bool
+}
+
+// TuringPitches is the number of distinct pitches a Turing register produces
+const TuringPitches = 256
+
+// NewTuringRegister returns a register of random bits.
+// The same seed always yields the same register.
+func NewTuringRegister(seed int64) uint32 {
+	return rand.New(rand.NewSource(seed)).Uint32()
+}
+
+// Generate returns the gate pattern of the loop
+func (t Turing) Generate() (*Pattern, error) {
+
+	if t.K == 0 {
+		return nil, ErrNoSteps
+	}
+	if t.K > 32 {
+		t.K = 32
+	}
+
+	p := &Pattern{Rhythm: make([]uint8, t.K)}
+	for i := range p.Rhythm {
+		p.Rhythm[i] = t.bit(i)
+	}
+
+	p.rotate(t.Rotation)
+
+	return p, nil
+}
+
+// Pitches returns the pitch (0-255) of count steps, repeating the loop
+func (t Turing) Pitches(count int) []uint8 {
+
+	pitches := make([]uint8, count)
+	if t.K == 0 {
+		return pitches
+	}
+
+	for i := range pitches {
+		for j := 0; j < 8; j++ {
+			pitches[i] |= t.bit(i+int(t.Rotation)+j) << j
+		}
+	}
+
+	return pitches
+}
+
+// Next returns the register after one clock: the loop shifted one step,
+// so step 1 becomes step 0, and the bit wrapping around to its last step
+// flipped with a chance of Flip percent drawn from r. Bits past the loop
+// are kept as they are.
+func (t Turing) Next(r *rand.Rand) uint32 {
+
+	k := uint(t.K)
+	if k == 0 {
+		return t.Register
+	}
+	if k > 32 {
+		k = 32
+	}
+
+	wrapped := t.Register & 1
+	if !t.Locked && t.Flip > 0 && r.Float64()*100 < t.Flip {
+		wrapped ^= 1
+	}
+
+	loop := uint32(1<<k - 1)
+	shifted := (t.Register&loop)>>1 | wrapped<<(k-1)
+
+	return t.Register&^loop | shifted
+}
+
+// bit returns bit i of the loop, repeating the loop past its end
+func (t Turing) bit(i int) uint8 {
+	k := int(t.K)
+	if k > 32 {
+		k = 32
+	}
+	return uint8(t.Register>>uint(i%k)) & 1
+}
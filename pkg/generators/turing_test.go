@@ -0,0 +1,104 @@
+package generators
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// clock returns the gates of count clocks of t, the bit under step 0 on each
+func clock(t Turing, r *rand.Rand, count int) []uint8 {
+	gates := make([]uint8, count)
+	for i := range gates {
+		gates[i] = uint8(t.Register & 1)
+		t.Register = t.Next(r)
+	}
+	return gates
+}
+
+func TestTuringNextShifts(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name           string
+		register, want uint32
+		k              uint8
+	}{
+		{"one step", 0x0b, 0x85, 8},    // 00001011 -> 10000101
+		{"wraps bit 0", 0x01, 0x80, 8}, // 00000001 -> 10000000
+		{"keeps bits past the loop", 0xff00000e, 0xff000007, 4},
+		{"whole register", 0x80000001, 0xc0000000, 32},
+	}
+
+	for _, tt := range tests {
+		turing := Turing{Register: tt.register, K: tt.k}
+		if got := turing.Next(r); got != tt.want {
+			t.Errorf("%s: Next() = %#x, want %#x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTuringLockedRepeats(t *testing.T) {
+
+	for _, k := range []uint8{1, 5, 8, 16, 32} {
+		for _, flip := range []float64{0, 50, 100} {
+			register := NewTuringRegister(int64(k))
+			turing := Turing{Register: register, K: k, Flip: flip, Locked: flip > 0}
+			r := rand.New(rand.NewSource(2))
+
+			gates := clock(turing, r, 3*int(k))
+			for i := int(k); i < len(gates); i++ {
+				if gates[i] != gates[i-int(k)] {
+					t.Fatalf("K %d flip %v: clock %d = %d, want %d from a loop before", k, flip, i, gates[i], gates[i-int(k)])
+				}
+			}
+
+			for i := 0; i < int(k); i++ {
+				turing.Register = turing.Next(r)
+			}
+			if turing.Register != register {
+				t.Errorf("K %d flip %v: register %#x after a loop, want %#x", k, flip, turing.Register, register)
+			}
+		}
+	}
+}
+
+func TestTuringFlipsWrappedBit(t *testing.T) {
+
+	r := rand.New(rand.NewSource(3))
+	turing := Turing{Register: 0x0b, K: 8, Flip: 100}
+
+	// Only the wrapped bit flips, 1 at step 0 landing as 0 at step 7
+	if got := turing.Next(r); got != 0x05 {
+		t.Errorf("Next() = %#x, want %#x", got, 0x05)
+	}
+
+	// A loop of clocks flips every bit once, inverting the loop
+	register := turing.Register
+	for i := 0; i < int(turing.K); i++ {
+		turing.Register = turing.Next(r)
+	}
+	if turing.Register != ^register&0xff {
+		t.Errorf("register %#x after a loop, want %#x", turing.Register, ^register&0xff)
+	}
+}
+
+func TestTuringPitches(t *testing.T) {
+
+	for seed := int64(0); seed < 20; seed++ {
+		turing := Turing{Register: NewTuringRegister(seed), K: uint8(seed%32 + 1), Rotation: uint8(seed % 5)}
+		pitches := turing.Pitches(64)
+		if len(pitches) != 64 {
+			t.Fatalf("%d pitches, want 64", len(pitches))
+		}
+		for i, pitch := range pitches {
+			if int(pitch) >= TuringPitches {
+				t.Errorf("seed %d: pitch %d = %d, want below %d", seed, i, pitch, TuringPitches)
+			}
+			// The loop repeats every K steps
+			if i >= int(turing.K) && pitch != pitches[i-int(turing.K)] {
+				t.Errorf("seed %d: pitch %d = %d, want %d from a loop before", seed, i, pitch, pitches[i-int(turing.K)])
+			}
+		}
+	}
+}
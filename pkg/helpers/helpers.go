@@ -54,6 +54,25 @@ func ConstrainUInt8(n, low, high uint8) uint8 {
 	}
 }
 
+func ConstrainInt(n, low, high int) int {
+	switch {
+	case n < low:
+		return low
+	case n > high:
+		return high
+	default:
+		return n
+	}
+}
+
+// BoolToFloat64 returns 1 for true and 0 for false
+func BoolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func PosInBounds(pos pixel.Vec, rect pixel.Rect) bool {
 	return rect.Contains(pos)
 }
@@ -8,6 +8,10 @@ import (
 	"github.com/willgarrison/go-noise/pkg/signals"
 )
 
+// TicksPerBeat is the number of ticks sent for each beat, the resolution of
+// MIDI clock, so notes can be timed and swung in fine divisions of a beat
+const TicksPerBeat = 24
+
 type Metronome struct {
 	Period              time.Duration
 	Ticker              *time.Ticker
@@ -23,7 +27,7 @@ func New(sessionData *session.SessionData) *Metronome {
 
 	m := &Metronome{
 		Period:      period,
-		Ticker:      time.NewTicker(period),
+		Ticker:      time.NewTicker(period / TicksPerBeat),
 		SessionData: sessionData,
 	}
 
@@ -42,19 +46,29 @@ func (m *Metronome) SetBpm(bpm uint32) {
 	m.SetPeriod(period)
 }
 
+// SetPeriod sets the length of a beat, ticking TicksPerBeat times within it
 func (m *Metronome) SetPeriod(period time.Duration) {
 	m.Period = period
-	m.Ticker.Reset(period)
+	m.Ticker.Reset(period / TicksPerBeat)
 }
 
+// Start sends a "beat" signal on the first tick of each beat
+// and a "tick" signal on the ticks in between
 func (m *Metronome) Start() {
 	go func() {
+		tick := 0
 		for {
 			<-m.Ticker.C
 			signal := signals.Signal{
+				Label: "beat",
 				Value: 1,
 			}
+			if tick != 0 {
+				signal.Label = "tick"
+				signal.Value = float64(tick)
+			}
 			m.SendToOutputChannels(signal)
+			tick = (tick + 1) % TicksPerBeat
 		}
 	}()
 }
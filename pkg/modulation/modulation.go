@@ -0,0 +1,78 @@
+package modulation
+
+import (
+	"math"
+
+	"github.com/willgarrison/go-noise/pkg/helpers"
+	"github.com/willgarrison/go-noise/pkg/noise"
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
+)
+
+// laneY keeps each lane on its own line of the noise field,
+// apart from the melody, velocity and gate lines
+const laneY float32 = 3000.5
+
+// Lane sends a MIDI control change that follows a noise field
+type Lane struct {
+	On        bool
+	CC        uint8
+	Channel   uint8 // 1-16
+	Min, Max  uint8
+	Rate      uint8 // messages per beat
+	Frequency float64
+	Offset    uint32
+	Octaves   uint8
+}
+
+// NewLanes returns count lanes, switched off, on consecutive CC numbers from 1
+func NewLanes(count int) []Lane {
+
+	lanes := make([]Lane, count)
+	for i := range lanes {
+		lanes[i] = Lane{
+			CC:        uint8(i + 1),
+			Channel:   1,
+			Min:       0,
+			Max:       127,
+			Rate:      4,
+			Frequency: 0.1,
+			Offset:    uint32(helpers.RandIntInRange(1, 999)),
+			Octaves:   2,
+		}
+	}
+
+	return lanes
+}
+
+// Due reports whether the lane sends a message on tick. Each beat holds
+// exactly Rate messages, spread as evenly as the ticks allow.
+func (l *Lane) Due(tick uint64, ticksPerBeat int) bool {
+
+	if !l.On || l.Rate == 0 {
+		return false
+	}
+
+	return tick == 0 || l.messageAt(tick, ticksPerBeat) != l.messageAt(tick-1, ticksPerBeat)
+}
+
+// messageAt returns the index of the message due by tick, counting from the
+// start of playback
+func (l *Lane) messageAt(tick uint64, ticksPerBeat int) uint64 {
+	return tick * uint64(l.Rate) / uint64(ticksPerBeat)
+}
+
+// Value samples lane index at a time in beats and maps it to the lane's range
+func (l *Lane) Value(src noise.NoiseSource, index int, beats float64) uint8 {
+
+	params := noise.Params{
+		Frequency:  float32(l.Frequency),
+		Lacunarity: 2,
+		Gain:       0.5,
+		Octaves:    int(l.Octaves),
+		Fractal:    simplexnoise.FractalNormalized,
+	}
+
+	val := src.Sample(float32(float64(l.Offset)+beats), laneY+float32(index)*100, params)
+
+	return uint8(math.Round(helpers.ReRange(float64(val), -1, 1, float64(l.Min), float64(l.Max))))
+}
@@ -0,0 +1,46 @@
+package modulation
+
+import "testing"
+
+const ticksPerBeat = 24
+
+func TestDueRate(t *testing.T) {
+
+	for rate := uint8(1); rate <= ticksPerBeat; rate++ {
+
+		l := Lane{On: true, Rate: rate}
+
+		due := []uint64{}
+		for tick := uint64(0); tick < 4*ticksPerBeat; tick++ {
+			if l.Due(tick, ticksPerBeat) {
+				due = append(due, tick)
+			}
+		}
+
+		if len(due) != 4*int(rate) {
+			t.Errorf("rate %d: %d messages in 4 beats, want %d", rate, len(due), 4*int(rate))
+			continue
+		}
+		if due[0] != 0 {
+			t.Errorf("rate %d: first message on tick %d, want 0", rate, due[0])
+		}
+
+		// Spaced as evenly as whole ticks allow
+		shortest := ticksPerBeat / uint64(rate)
+		for i := 1; i < len(due); i++ {
+			if gap := due[i] - due[i-1]; gap < shortest || gap > shortest+1 {
+				t.Errorf("rate %d: gap of %d ticks before tick %d", rate, gap, due[i])
+			}
+		}
+	}
+}
+
+func TestDueOff(t *testing.T) {
+	for _, l := range []Lane{{On: false, Rate: 4}, {On: true, Rate: 0}} {
+		for tick := uint64(0); tick < ticksPerBeat; tick++ {
+			if l.Due(tick, ticksPerBeat) {
+				t.Errorf("%+v due on tick %d", l, tick)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package noise
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
+)
+
+// NoiseSource is a seeded noise field that can be sampled at x,y
+type NoiseSource interface {
+	Sample(x, y float32, p Params) float32
+}
+
+// Looper is a NoiseSource with its own seamless loop sampling
+type Looper interface {
+	Loop(t, radius, x, y float32, p Params) float32
+}
+
+// Params control how a NoiseSource is sampled
+type Params struct {
+	Frequency  float32
+	Lacunarity float32
+	Gain       float32
+	Octaves    int
+	Fractal    simplexnoise.Fractal
+}
+
+// Source selects a NoiseSource implementation
+type Source uint8
+
+const (
+	SourceSimplex Source = iota
+	SourcePerlin
+	SourceValue
+	SourceWorley
+	SourceCellular
+)
+
+// SourceNames are short labels for each Source, indexed by value
+var SourceNames = []string{"simplex", "perlin", "value", "worley", "cell"}
+
+// String returns the short label of the source
+func (s Source) String() string {
+	if int(s) < len(SourceNames) {
+		return SourceNames[s]
+	}
+	return "unknown"
+}
+
+// New returns the NoiseSource selected by source, built from seed
+func New(source Source, seed int64) NoiseSource {
+	switch source {
+	case SourcePerlin:
+		return NewPerlin(seed)
+	case SourceValue:
+		return NewValue(seed)
+	case SourceWorley:
+		return NewWorley(seed, false)
+	case SourceCellular:
+		return NewWorley(seed, true)
+	default:
+		return Simplex{simplexnoise.New(seed)}
+	}
+}
+
+// Simplex samples a simplexnoise.Generator
+type Simplex struct {
+	*simplexnoise.Generator
+}
+
+// Sample returns the fractal noise at x,y
+func (s Simplex) Sample(x, y float32, p Params) float32 {
+	return s.FractalNoise(p.Fractal, x, y, p.Frequency, p.Lacunarity, p.Gain, p.Octaves)
+}
+
+// fractal combines octaves of a single octave basis b at x,y. FractalFbm
+// is scaled down to the range of simplex fbm, which the grid assumes.
+func fractal(b simplexnoise.Basis, x, y float32, p Params) float32 {
+	if p.Fractal == simplexnoise.FractalFbm {
+		b = simplexnoise.FbmBasis(b)
+	}
+	return simplexnoise.FractalBasis(b, p.Fractal, x, y, p.Frequency, p.Lacunarity, p.Gain, p.Octaves)
+}
+
+// Loop samples the fractal noise around a circle of the given radius,
+// so t=1 flows seamlessly into t=0. Sources without their own loop trace
+// the circle in the x,y plane, centered on x,y.
+func Loop(src NoiseSource, t, radius, x, y float32, p Params) float32 {
+
+	if looper, ok := src.(Looper); ok {
+		return looper.Loop(t, radius, x, y, p)
+	}
+
+	angle := 2 * math.Pi * float64(t)
+	cx := x + radius*float32(math.Cos(angle))
+	cy := y + radius*float32(math.Sin(angle))
+
+	return src.Sample(cx, cy, p)
+}
+
+// Loop samples a circle in 4D simplex noise, evolving along y with x selecting the loop
+func (s Simplex) Loop(t, radius, x, y float32, p Params) float32 {
+	return s.LoopNoise(p.Fractal, t, radius, y, x, p.Frequency, p.Lacunarity, p.Gain, p.Octaves)
+}
+
+// permutation returns the numbers 0-255 shuffled from seed
+func permutation(seed int64) [256]uint8 {
+
+	var perm [256]uint8
+	for i := range perm {
+		perm[i] = uint8(i)
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	r.Shuffle(len(perm), func(i, j int) {
+		perm[i], perm[j] = perm[j], perm[i]
+	})
+
+	return perm
+}
+
+// fastFloor rounds x down to the nearest integer
+func fastFloor(x float32) int {
+	if float32(int(x)) <= x {
+		return int(x)
+	}
+	return int(x) - 1
+}
+
+// fade is Perlin's quintic smoothstep, 6t^5 - 15t^4 + 10t^3
+func fade(t float32) float32 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float32) float32 {
+	return a + t*(b-a)
+}
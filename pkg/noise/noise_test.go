@@ -0,0 +1,49 @@
+package noise
+
+import (
+	"testing"
+
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
+)
+
+func TestFbmRange(t *testing.T) {
+
+	p := Params{Frequency: 0.3, Lacunarity: 2, Gain: 2, Octaves: 4, Fractal: simplexnoise.FractalFbm}
+
+	// Each octave is within [-1, 1] before fbm scales it down like simplex noise
+	bound := float32(1+2+4+8) / 40
+
+	for source := SourceSimplex; source <= SourceCellular; source++ {
+		src := New(source, 7)
+		for i := 0; i < 64; i++ {
+			for j := 0; j < 48; j++ {
+				x, y := float32(i)*0.37, float32(j)*0.53
+				if v := src.Sample(x, y, p); v < -bound || v > bound {
+					t.Fatalf("%v: Sample(%v, %v) = %v, want within ±%v", source, x, y, v, bound)
+				}
+				if v := Loop(src, float32(i)/64, 10, 0, y, p); v < -bound || v > bound {
+					t.Fatalf("%v: Loop(%v) = %v, want within ±%v", source, float32(i)/64, v, bound)
+				}
+			}
+		}
+	}
+}
+
+func TestFractalRange(t *testing.T) {
+
+	p := Params{Frequency: 0.3, Lacunarity: 2, Gain: 2, Octaves: 4}
+
+	for source := SourceSimplex; source <= SourceCellular; source++ {
+		src := New(source, 7)
+		for f := simplexnoise.FractalNormalized; f <= simplexnoise.FractalHybrid; f++ {
+			p.Fractal = f
+			low, high := f.Range()
+			for i := 0; i < 64; i++ {
+				x, y := float32(i)*0.37, float32(i)*0.53
+				if v := src.Sample(x, y, p); v < low || v > high {
+					t.Fatalf("%v %v: Sample(%v, %v) = %v, want within [%v, %v]", source, f, x, y, v, low, high)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package noise
+
+// Perlin is classic gradient noise on a square lattice, using the
+// quintic fade curve from Ken Perlin's improved noise
+type Perlin struct {
+	perm [256]uint8
+}
+
+// NewPerlin returns Perlin noise with a permutation table shuffled from seed
+func NewPerlin(seed int64) *Perlin {
+	return &Perlin{perm: permutation(seed)}
+}
+
+// Noise returns a single octave of Perlin noise at x,y, in [-1, 1]
+func (n *Perlin) Noise(x, y float32) float32 {
+
+	i := fastFloor(x)
+	j := fastFloor(y)
+
+	// Distances from the lower left lattice point
+	x0 := x - float32(i)
+	y0 := y - float32(j)
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+
+	// Gradient contributions from the four corners of the cell
+	n00 := perlinGrad(n.perm[ii+n.perm[jj]], x0, y0)
+	n10 := perlinGrad(n.perm[ii+1+n.perm[jj]], x0-1, y0)
+	n01 := perlinGrad(n.perm[ii+n.perm[jj+1]], x0, y0-1)
+	n11 := perlinGrad(n.perm[ii+1+n.perm[jj+1]], x0-1, y0-1)
+
+	u := fade(x0)
+	v := fade(y0)
+
+	// Unit gradients reach about 0.7071 in 2D, so scale up to [-1, 1]
+	return 1.4142 * lerp(v, lerp(u, n00, n10), lerp(u, n01, n11))
+}
+
+// Sample returns the fractal noise at x,y
+func (n *Perlin) Sample(x, y float32, p Params) float32 {
+	return fractal(n.Noise, x, y, p)
+}
+
+// gradients are 8 unit directions, 4 along the axes and 4 diagonals
+var gradients = [8][2]float32{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{0.7071, 0.7071}, {-0.7071, 0.7071}, {0.7071, -0.7071}, {-0.7071, -0.7071},
+}
+
+// perlinGrad picks one of 8 gradient directions from the low 3 bits
+// of hash and returns its dot product with (x,y)
+func perlinGrad(hash uint8, x, y float32) float32 {
+	g := gradients[hash&7]
+	return g[0]*x + g[1]*y
+}
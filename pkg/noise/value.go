@@ -0,0 +1,43 @@
+package noise
+
+// Value is value noise: random values on a square lattice,
+// smoothly interpolated between lattice points
+type Value struct {
+	perm [256]uint8
+}
+
+// NewValue returns value noise with a permutation table shuffled from seed
+func NewValue(seed int64) *Value {
+	return &Value{perm: permutation(seed)}
+}
+
+// Noise returns a single octave of value noise at x,y, in [-1, 1]
+func (n *Value) Noise(x, y float32) float32 {
+
+	i := fastFloor(x)
+	j := fastFloor(y)
+
+	u := fade(x - float32(i))
+	v := fade(y - float32(j))
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+
+	v00 := n.lattice(ii, jj)
+	v10 := n.lattice(ii+1, jj)
+	v01 := n.lattice(ii, jj+1)
+	v11 := n.lattice(ii+1, jj+1)
+
+	return lerp(v, lerp(u, v00, v10), lerp(u, v01, v11))
+}
+
+// Sample returns the fractal noise at x,y
+func (n *Value) Sample(x, y float32, p Params) float32 {
+	return fractal(n.Noise, x, y, p)
+}
+
+// lattice returns the random value at a lattice point, in [-1, 1]
+func (n *Value) lattice(i, j uint8) float32 {
+	return float32(n.perm[i+n.perm[j]])/127.5 - 1
+}
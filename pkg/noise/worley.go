@@ -0,0 +1,71 @@
+package noise
+
+import "math"
+
+// Worley is cellular noise: one jittered feature point per lattice cell,
+// measured against the nearest point to x,y
+type Worley struct {
+	perm [256]uint8
+	// CellValue returns the random value of the nearest feature point
+	// instead of the distance to it, giving flat, stepped contours
+	CellValue bool
+}
+
+// NewWorley returns Worley noise with a permutation table shuffled from seed
+func NewWorley(seed int64, cellValue bool) *Worley {
+	return &Worley{
+		perm:      permutation(seed),
+		CellValue: cellValue,
+	}
+}
+
+// Noise returns a single octave of Worley noise at x,y, in [-1, 1]
+func (n *Worley) Noise(x, y float32) float32 {
+
+	i := fastFloor(x)
+	j := fastFloor(y)
+
+	nearest := float32(math.MaxFloat32)
+	var nearestHash uint8
+
+	// The nearest feature point is always within the surrounding 3x3 cells
+	for dj := -1; dj <= 1; dj++ {
+		for di := -1; di <= 1; di++ {
+
+			// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+			ci := uint8(i + di)
+			cj := uint8(j + dj)
+			hash := n.perm[ci+n.perm[cj]]
+
+			// Jitter the feature point inside its cell
+			px := float32(i+di) + float32(hash)/255
+			py := float32(j+dj) + float32(n.perm[hash])/255
+
+			dx := px - x
+			dy := py - y
+			d := dx*dx + dy*dy
+
+			if d < nearest {
+				nearest = d
+				nearestHash = hash
+			}
+		}
+	}
+
+	if n.CellValue {
+		return float32(n.perm[nearestHash+1])/127.5 - 1
+	}
+
+	// Distances to the nearest point rarely exceed 1
+	distance := float32(math.Sqrt(float64(nearest)))
+	if distance > 1 {
+		distance = 1
+	}
+
+	return distance*2 - 1
+}
+
+// Sample returns the fractal noise at x,y
+func (n *Worley) Sample(x, y float32, p Params) float32 {
+	return fractal(n.Noise, x, y, p)
+}
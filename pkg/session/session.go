@@ -15,11 +15,25 @@ import (
 	"github.com/gen2brain/dlgs"
 	"github.com/willgarrison/go-noise/pkg/generators"
 	"github.com/willgarrison/go-noise/pkg/helpers"
+	"github.com/willgarrison/go-noise/pkg/modulation"
+	"github.com/willgarrison/go-noise/pkg/noise"
 	"github.com/willgarrison/go-noise/pkg/signals"
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
 )
 
 var lock sync.Mutex
 
+const (
+	// MaxColumns and MaxRows are the size of the per-cell arrays, the most
+	// steps and rows a grid can have
+	MaxColumns = 64
+	MaxRows    = 48
+	// LayerCount is the number of polymeter layers a session holds
+	LayerCount = 3
+	// LaneCount is the number of modulation lanes a session holds
+	LaneCount = 4
+)
+
 type Session struct {
 	InputCtrlChannel chan signals.Signal
 	OutputChannels   []chan signals.Signal
@@ -28,8 +42,12 @@ type Session struct {
 
 type SessionData struct {
 	UserMatrix       [][]uint32
+	UserVelocity     [][]uint8
+	UserGate         [][]float64
+	UserOrder        [][]uint32 // Order the cells of each column were placed in, from 1
 	UserPattern      *generators.Pattern
 	KeyboardNumInput string
+	Seed             int64
 	Frequency        float64
 	Lacunarity       float64
 	Gain             float64
@@ -42,6 +60,59 @@ type SessionData struct {
 	Release          uint8
 	N, K, R          uint8 // Pattern Variables
 	G                float64
+	Algorithm        generators.Algorithm
+	Rhythm           generators.Kind // Rhythm Generator Variables
+	RhythmDensity    float64
+	RhythmTimeline   uint8
+	RhythmThreshold  float64
+	Notation         string
+	AccentN          uint8 // Accent Variables, accents over the hits
+	AccentR          uint8
+	AccentLevel      uint8
+	Chance           uint8 // Variation Variables, percent
+	Ratchet          uint8
+	Swing            float64            // Timing Variables, percent
+	Microtiming      []float64          // Per column offset, in steps
+	Layers           []generators.Layer // Polymeter Variables
+	Layer            uint8              // Selected layer
+	Melody           generators.Melody  // Melody Variables, the source of the grid's notes
+	CARule           uint8
+	CASeed           int64
+	CAGeneration     uint32
+	Markov           generators.Markov // Trained from the user's cells or a MIDI file
+	MarkovRandomness float64
+	TuringRegister   uint32 // Shift register shared by the turing rhythm and melody
+	TuringFlip       float64
+	TuringLocked     bool
+	LSystem          string             // Axiom and rules, see generators.ParseLSystem
+	LSystemDepth     uint8              // Iterations
+	ArpMode          generators.ArpMode // Arpeggiator Variables
+	ArpOctaves       uint8
+	ArpRate          uint8        // Notes per beat
+	Source           noise.Source // Noise Variables
+	Fractal          simplexnoise.Fractal
+	WarpFrequency    float64
+	WarpAmount       float64
+	Drift            float64 // Evolve: y advance per loop
+	EvolveY          float64
+	Loop             bool
+	Voices           uint8 // Voice Variables
+	VoiceSpread      float64
+	VoiceMode        uint8
+	VoiceInterval    uint8
+	VelocityMode     uint8 // Velocity Variables
+	Velocity         uint8
+	VelocityFreq     float64
+	VelocityOffset   uint32
+	VelocityMin      uint8
+	VelocityMax      uint8
+	GateMode         uint8 // Gate Variables, in beats
+	GateFreq         float64
+	GateOffset       uint32
+	GateMin          float64
+	GateMax          float64
+	ModLanes         []modulation.Lane // Modulation Variables
+	ModLane          uint8             // Selected lane
 }
 
 func NewSession() *Session {
@@ -58,15 +129,31 @@ func NewSession() *Session {
 
 func (s *Session) InitializeSessionData() {
 
-	s.SessionData.UserMatrix = make([][]uint32, 64)
+	s.SessionData.UserMatrix = make([][]uint32, MaxColumns)
 	for i := range s.SessionData.UserMatrix {
-		s.SessionData.UserMatrix[i] = make([]uint32, 48)
+		s.SessionData.UserMatrix[i] = make([]uint32, MaxRows)
+	}
+
+	s.SessionData.UserVelocity = make([][]uint8, MaxColumns)
+	for i := range s.SessionData.UserVelocity {
+		s.SessionData.UserVelocity[i] = make([]uint8, MaxRows)
+	}
+
+	s.SessionData.UserGate = make([][]float64, MaxColumns)
+	for i := range s.SessionData.UserGate {
+		s.SessionData.UserGate[i] = make([]float64, MaxRows)
+	}
+
+	s.SessionData.UserOrder = make([][]uint32, MaxColumns)
+	for i := range s.SessionData.UserOrder {
+		s.SessionData.UserOrder[i] = make([]uint32, MaxRows)
 	}
 
 	// set a random seed
 	rand.Seed(time.Now().UnixNano())
 
 	// Set default parameters to random values
+	s.SessionData.Seed = int64(helpers.RandIntInRange(1, 9999))
 	s.SessionData.Frequency = 0.3
 	s.SessionData.Lacunarity = 0.9
 	s.SessionData.Gain = helpers.RandFloatInRange(1.5, 3.0)
@@ -89,7 +176,152 @@ func (s *Session) InitializeSessionData() {
 	s.SessionData.R = 0
 	s.SessionData.G = 0
 
-	s.SessionData.UserPattern, _ = generators.NewEuclid(s.SessionData.N, s.SessionData.K, s.SessionData.R, s.SessionData.G)
+	// Rhythm
+	s.SessionData.Rhythm = generators.KindEuclid
+	s.SessionData.RhythmDensity = 0.5
+	s.SessionData.RhythmTimeline = 0
+	s.SessionData.RhythmThreshold = 0
+	s.SessionData.Notation = "x..x..x."
+
+	// Accents
+	s.SessionData.AccentN = 0
+	s.SessionData.AccentR = 0
+	s.SessionData.AccentLevel = generators.AccentStrong
+
+	// Variation
+	s.SessionData.Chance = 100
+	s.SessionData.Ratchet = 1
+
+	// Timing
+	s.SessionData.Swing = 50
+	s.SessionData.Microtiming = make([]float64, MaxColumns)
+
+	// Polymeter
+	s.SessionData.Layers = generators.NewLayers(LayerCount)
+	s.SessionData.Layer = 0
+
+	// Melody
+	s.SessionData.Melody = generators.MelodyNoise
+	s.SessionData.CARule = 30
+	s.SessionData.CASeed = 0
+	s.SessionData.CAGeneration = 0
+	s.SessionData.Markov = generators.Markov{Order: 1}
+	s.SessionData.MarkovRandomness = 0
+	s.SessionData.TuringRegister = generators.NewTuringRegister(s.SessionData.Seed)
+	s.SessionData.TuringFlip = 10
+	s.SessionData.TuringLocked = false
+	s.SessionData.LSystem = "F; F=F+F--F+F"
+	s.SessionData.LSystemDepth = 2
+
+	// Arpeggiator
+	s.SessionData.ArpMode = generators.ArpOff
+	s.SessionData.ArpOctaves = 1
+	s.SessionData.ArpRate = 4
+
+	// Noise
+	s.SessionData.Source = noise.SourceSimplex
+	s.SessionData.Fractal = simplexnoise.FractalNormalized
+	s.SessionData.WarpFrequency = 0.1
+	s.SessionData.WarpAmount = 0
+	s.SessionData.Drift = 0
+	s.SessionData.EvolveY = 0
+	s.SessionData.Loop = false
+
+	// Voices
+	s.SessionData.Voices = 1
+	s.SessionData.VoiceSpread = 10
+	s.SessionData.VoiceMode = 0 // free
+	s.SessionData.VoiceInterval = 2
+
+	// Velocity
+	s.SessionData.VelocityMode = 1 // noise
+	s.SessionData.Velocity = 100
+	s.SessionData.VelocityFreq = 0.2
+	s.SessionData.VelocityOffset = uint32(helpers.RandIntInRange(1, 999))
+	s.SessionData.VelocityMin = 51
+	s.SessionData.VelocityMax = 100
+
+	// Gate
+	s.SessionData.GateMode = 0 // fixed
+	s.SessionData.GateFreq = 0.2
+	s.SessionData.GateOffset = uint32(helpers.RandIntInRange(1, 999))
+	s.SessionData.GateMin = 0.25
+	s.SessionData.GateMax = 2
+
+	// Modulation
+	s.SessionData.ModLanes = modulation.NewLanes(LaneCount)
+	s.SessionData.ModLane = 0
+
+	s.SessionData.UserPattern, _ = s.SessionData.RhythmGenerator().Generate()
+}
+
+// RhythmGenerator returns the rhythm generator selected on the control board,
+// configured from the session, accented by the accent layer and varied by
+// the chance and ratchet dials
+func (sd *SessionData) RhythmGenerator() generators.RhythmGenerator {
+	return generators.Varied{
+		Generator: generators.Accented{
+			Generator: sd.baseRhythmGenerator(),
+			N:         sd.AccentN,
+			Rotation:  sd.AccentR,
+			Level:     sd.AccentLevel,
+		},
+		Chance:  sd.Chance,
+		Ratchet: sd.Ratchet,
+	}
+}
+
+func (sd *SessionData) baseRhythmGenerator() generators.RhythmGenerator {
+
+	switch sd.Rhythm {
+	case generators.KindDensity:
+		return generators.Density{K: sd.K, Rotation: sd.R, Density: sd.RhythmDensity, Seed: sd.Seed}
+	case generators.KindTimeline:
+		return generators.Timeline{Index: sd.RhythmTimeline, Rotation: sd.R}
+	case generators.KindFibonacci:
+		return generators.Fibonacci{K: sd.K, Rotation: sd.R}
+	case generators.KindAutomaton:
+		return generators.AutomatonRhythm{
+			Automaton:  generators.Automaton{Rule: sd.CARule, Seed: sd.CASeed},
+			K:          sd.K,
+			Rotation:   sd.R,
+			Generation: sd.CAGeneration,
+		}
+	case generators.KindNotation:
+		return generators.Notation{Text: sd.Notation}
+	case generators.KindTuring:
+		return sd.Turing()
+	case generators.KindNoise:
+		return generators.NoiseThreshold{
+			K:          sd.K,
+			Rotation:   sd.R,
+			Threshold:  sd.RhythmThreshold,
+			Seed:       sd.Seed,
+			Offset:     sd.Offset,
+			Frequency:  sd.Frequency,
+			Lacunarity: sd.Lacunarity,
+			Gain:       sd.Gain,
+			Octaves:    sd.Octaves,
+		}
+	}
+
+	// The n and k dials may be set in either order
+	n, k := sd.N, sd.K
+	if n > k {
+		n, k = k, n
+	}
+	return generators.Euclid{N: n, K: k, Rotation: sd.R, Groove: sd.G, Algorithm: sd.Algorithm}
+}
+
+// Turing returns the turing machine looping over K steps
+func (sd *SessionData) Turing() generators.Turing {
+	return generators.Turing{
+		Register: sd.TuringRegister,
+		K:        sd.K,
+		Rotation: sd.R,
+		Flip:     sd.TuringFlip,
+		Locked:   sd.TuringLocked,
+	}
 }
 
 func (s *Session) Save(path string) error {
@@ -123,7 +355,98 @@ func (s *Session) Load(path string) error {
 	}
 	defer f.Close()
 
-	return json.NewDecoder(f).Decode(&s.SessionData)
+	// Decode into fresh data, so nothing of the current session carries over
+	sd := loadDefaults()
+	if err := json.NewDecoder(f).Decode(&sd); err != nil {
+		return err
+	}
+	sd.normalize()
+
+	s.SessionData = sd
+
+	return nil
+}
+
+// loadDefaults returns the session data a file is decoded into. A session
+// saved before a field existed leaves it out of the file; the field must
+// then keep the behaviour the session was saved with.
+func loadDefaults() SessionData {
+
+	defaults := new(Session)
+	defaults.InitializeSessionData()
+
+	sd := defaults.SessionData
+	sd.Seed = 0 // Reference permutation table
+	sd.Fractal = simplexnoise.FractalFbm
+
+	return sd
+}
+
+// normalize repairs what a file may hold that the grid and controls cannot
+// index: per-cell arrays of the wrong size, and more or fewer layers and
+// lanes than the controls offer
+func (sd *SessionData) normalize() {
+
+	if !sized(len(sd.UserMatrix), func(i int) int { return len(sd.UserMatrix[i]) }) {
+		sd.UserMatrix = make([][]uint32, MaxColumns)
+		for i := range sd.UserMatrix {
+			sd.UserMatrix[i] = make([]uint32, MaxRows)
+		}
+	}
+	if !sized(len(sd.UserVelocity), func(i int) int { return len(sd.UserVelocity[i]) }) {
+		sd.UserVelocity = make([][]uint8, MaxColumns)
+		for i := range sd.UserVelocity {
+			sd.UserVelocity[i] = make([]uint8, MaxRows)
+		}
+	}
+	if !sized(len(sd.UserGate), func(i int) int { return len(sd.UserGate[i]) }) {
+		sd.UserGate = make([][]float64, MaxColumns)
+		for i := range sd.UserGate {
+			sd.UserGate[i] = make([]float64, MaxRows)
+		}
+	}
+	if !sized(len(sd.UserOrder), func(i int) int { return len(sd.UserOrder[i]) }) {
+		sd.UserOrder = make([][]uint32, MaxColumns)
+		for i := range sd.UserOrder {
+			sd.UserOrder[i] = make([]uint32, MaxRows)
+		}
+	}
+	if len(sd.Microtiming) != MaxColumns {
+		sd.Microtiming = append(sd.Microtiming, make([]float64, MaxColumns)...)[:MaxColumns]
+	}
+
+	if len(sd.Layers) > LayerCount {
+		sd.Layers = sd.Layers[:LayerCount]
+	}
+	if len(sd.Layers) < LayerCount {
+		sd.Layers = append(sd.Layers, generators.NewLayers(LayerCount-len(sd.Layers))...)
+	}
+	if int(sd.Layer) >= LayerCount {
+		sd.Layer = 0
+	}
+	if len(sd.ModLanes) > LaneCount {
+		sd.ModLanes = sd.ModLanes[:LaneCount]
+	}
+	if len(sd.ModLanes) < LaneCount {
+		sd.ModLanes = append(sd.ModLanes, modulation.NewLanes(LaneCount-len(sd.ModLanes))...)
+	}
+	if int(sd.ModLane) >= LaneCount {
+		sd.ModLane = 0
+	}
+}
+
+// sized reports whether a per-cell array of columns columns, whose column
+// i has rows(i) rows, is MaxColumns by MaxRows
+func sized(columns int, rows func(i int) int) bool {
+	if columns != MaxColumns {
+		return false
+	}
+	for i := 0; i < columns; i++ {
+		if rows(i) != MaxRows {
+			return false
+		}
+	}
+	return true
 }
 
 func (s *Session) ListenToInputCtrlChannel() {
@@ -165,6 +488,82 @@ func (s *Session) ListenToInputCtrlChannel() {
 					s.SendToOutputChannels(signal)
 				}
 
+			case "text":
+
+				entered, ok, err := dlgs.Entry("Rhythm", "Pattern, e.g. x..x..x. or E(3,8):", s.SessionData.Notation)
+				if err != nil {
+					log.Println("dlgs.Entry:", err)
+				}
+				if !ok {
+					break
+				}
+
+				_, err = generators.Parse(entered)
+				if err != nil {
+					log.Println("generators.Parse:", err)
+					dlgs.Error("Rhythm", err.Error())
+					break
+				}
+
+				s.SessionData.Notation = entered
+				s.SessionData.Rhythm = generators.KindNotation
+
+				signal := signals.Signal{
+					Label: "notated",
+				}
+				s.SendToOutputChannels(signal)
+
+			case "midi":
+
+				selectedFile, ok, err := dlgs.File("Select a MIDI file:", "*.mid", false)
+				if err != nil {
+					log.Println("dlgs.File:", err)
+				}
+				if !ok {
+					break
+				}
+
+				// Steps are sixteenth notes
+				notes, err := generators.ReadMIDI(selectedFile, 4)
+				if err != nil {
+					log.Println("generators.ReadMIDI:", err)
+					dlgs.Error("Markov", err.Error())
+					break
+				}
+
+				s.SessionData.Markov.Train(generators.NewLine(notes, 0))
+				s.SessionData.Melody = generators.MelodyMarkov
+
+				signal := signals.Signal{
+					Label: "trained",
+				}
+				s.SendToOutputChannels(signal)
+
+			case "rules":
+
+				entered, ok, err := dlgs.Entry("L-system", "Axiom; rules, e.g. F; F=F+F--F+F:", s.SessionData.LSystem)
+				if err != nil {
+					log.Println("dlgs.Entry:", err)
+				}
+				if !ok {
+					break
+				}
+
+				_, err = generators.ParseLSystem(entered)
+				if err != nil {
+					log.Println("generators.ParseLSystem:", err)
+					dlgs.Error("L-system", err.Error())
+					break
+				}
+
+				s.SessionData.LSystem = entered
+				s.SessionData.Melody = generators.MelodyLSystem
+
+				signal := signals.Signal{
+					Label: "rewritten",
+				}
+				s.SendToOutputChannels(signal)
+
 			case "load":
 
 				selectedFile, _, err := dlgs.File("Select file:", "", false)
@@ -0,0 +1,227 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/willgarrison/go-noise/pkg/generators"
+	"github.com/willgarrison/go-noise/pkg/noise"
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
+)
+
+// load returns a new session loaded from a file holding data
+func load(t *testing.T, data string) *Session {
+	t.Helper()
+	s := NewSession()
+	loadInto(t, s, data)
+	return s
+}
+
+// loadInto loads s from a file holding data
+func loadInto(t *testing.T, s *Session, data string) {
+
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "session.json")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Load(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// legacySession is a session saved before the seed and later fields existed
+const legacySession = `{"Frequency": 0.3, "Lacunarity": 0.9, "Gain": 2, "Octaves": 4,
+	"XSteps": 16, "YSteps": 24, "Offset": 42, "Bpm": 120, "Low": 36, "Release": 1,
+	"N": 5, "K": 8, "R": 0, "G": 0}`
+
+func TestLoadLegacyDefaults(t *testing.T) {
+
+	// Load over a session that has moved every later setting off its default
+	s := NewSession()
+	sd := &s.SessionData
+	sd.Melody = generators.MelodyLSystem
+	sd.Rhythm = generators.KindTuring
+	sd.Source = noise.SourcePerlin
+	sd.WarpAmount = 1
+	sd.Drift = 0.5
+	sd.Loop = true
+	sd.Voices = 3
+	sd.VelocityMode = 0
+	sd.GateMode = 1
+	sd.Swing = 60
+	sd.Chance = 50
+	sd.Ratchet = 3
+	sd.ArpMode = generators.ArpUp
+	sd.UserVelocity[1][2] = 99
+	sd.UserGate[1][2] = 2
+	sd.UserOrder[1][2] = 1
+	sd.Microtiming[1] = 0.25
+
+	loadInto(t, s, legacySession)
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"Melody", sd.Melody, generators.MelodyNoise},
+		{"Rhythm", sd.Rhythm, generators.KindEuclid},
+		{"Source", sd.Source, noise.SourceSimplex},
+		{"WarpAmount", sd.WarpAmount, 0.0},
+		{"Drift", sd.Drift, 0.0},
+		{"Loop", sd.Loop, false},
+		{"Voices", sd.Voices, uint8(1)},
+		{"VelocityMode", sd.VelocityMode, uint8(1)},
+		{"GateMode", sd.GateMode, uint8(0)},
+		{"Swing", sd.Swing, 50.0},
+		{"Chance", sd.Chance, uint8(100)},
+		{"Ratchet", sd.Ratchet, uint8(1)},
+		{"ArpMode", sd.ArpMode, generators.ArpOff},
+		{"UserVelocity", sd.UserVelocity[1][2], uint8(0)},
+		{"UserGate", sd.UserGate[1][2], 0.0},
+		{"UserOrder", sd.UserOrder[1][2], uint32(0)},
+		{"Microtiming", sd.Microtiming[1], 0.0},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if s.SessionData.Seed != 0 {
+		t.Errorf("Seed = %d, want 0 for the reference table", s.SessionData.Seed)
+	}
+	if s.SessionData.Fractal != simplexnoise.FractalFbm {
+		t.Errorf("Fractal = %v, want %v", s.SessionData.Fractal, simplexnoise.FractalFbm)
+	}
+	if s.SessionData.Offset != 42 || s.SessionData.N != 5 {
+		t.Errorf("Offset, N = %d, %d, want 42, 5", s.SessionData.Offset, s.SessionData.N)
+	}
+}
+
+func TestLoadNullArrays(t *testing.T) {
+
+	s := load(t, `{"UserMatrix": null, "UserVelocity": null, "UserGate": [[1]], "UserOrder": [], "Microtiming": [0.25]}`)
+
+	sd := &s.SessionData
+	for name, columns := range map[string]int{
+		"UserMatrix":   len(sd.UserMatrix),
+		"UserVelocity": len(sd.UserVelocity),
+		"UserGate":     len(sd.UserGate),
+		"UserOrder":    len(sd.UserOrder),
+		"Microtiming":  len(sd.Microtiming),
+	} {
+		if columns != MaxColumns {
+			t.Errorf("%s has %d columns, want %d", name, columns, MaxColumns)
+		}
+	}
+	if len(sd.UserVelocity[MaxColumns-1]) != MaxRows || len(sd.UserGate[0]) != MaxRows {
+		t.Errorf("columns of %d and %d rows, want %d", len(sd.UserVelocity[MaxColumns-1]), len(sd.UserGate[0]), MaxRows)
+	}
+	if sd.Microtiming[0] != 0.25 {
+		t.Errorf("Microtiming[0] = %v, want 0.25", sd.Microtiming[0])
+	}
+}
+
+func TestLoadMarkov(t *testing.T) {
+
+	s := NewSession()
+	s.SessionData.Markov.Train(generators.NewLine([]generators.Note{{Key: 0, Step: 0}, {Key: 7, Step: 1}}, 2))
+
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A session without a model loads untrained
+	path := filepath.Join(dir, "legacy.json")
+	if err := ioutil.WriteFile(path, []byte(legacySession), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if s.SessionData.Markov.Trained() {
+		t.Errorf("kept the current model: %v", s.SessionData.Markov.Transitions)
+	}
+
+	// A saved model replaces the current one rather than merging into it
+	s.SessionData.Markov.Train(generators.NewLine([]generators.Note{{Key: 0, Step: 0}, {Key: 7, Step: 1}}, 2))
+	path = filepath.Join(dir, "markov.json")
+	data := `{"Markov": {"Order": 1, "Transitions": {"2:1": [{"Interval": -2, "Duration": 1, "Count": 1}]}}}`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.SessionData.Markov.Transitions) != 1 {
+		t.Errorf("transitions = %v, want only those saved", s.SessionData.Markov.Transitions)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data string
+		on   []bool
+	}{
+		{"empty", `{"Layers": [], "Layer": 2}`, []bool{false, false, false}},
+		{"null", `{"Layers": null}`, []bool{false, false, false}},
+		{"short", `{"Layers": [{"On": true, "N": 3, "K": 8, "Num": 1, "Den": 1}], "Layer": 2}`, []bool{true, false, false}},
+		{"long", `{"Layers": [{"On": true}, {"On": true}, {"On": true}, {"On": true}], "Layer": 3}`, []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := load(t, tt.data)
+			if len(s.SessionData.Layers) != LayerCount {
+				t.Fatalf("%d layers, want %d", len(s.SessionData.Layers), LayerCount)
+			}
+			if int(s.SessionData.Layer) >= LayerCount {
+				t.Errorf("selected layer %d out of range", s.SessionData.Layer)
+			}
+			for i, on := range tt.on {
+				if s.SessionData.Layers[i].On != on {
+					t.Errorf("layer %d on = %v, want %v", i, s.SessionData.Layers[i].On, on)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadModLanes(t *testing.T) {
+	for _, data := range []string{`{"ModLanes": [], "ModLane": 3}`, `{"ModLanes": [{}, {}, {}, {}, {}], "ModLane": 4}`} {
+		s := load(t, data)
+		if len(s.SessionData.ModLanes) != LaneCount {
+			t.Errorf("%s: %d lanes, want %d", data, len(s.SessionData.ModLanes), LaneCount)
+		}
+		if int(s.SessionData.ModLane) >= LaneCount {
+			t.Errorf("%s: selected lane %d out of range", data, s.SessionData.ModLane)
+		}
+	}
+}
+
+func TestLoadKeepsSavedFields(t *testing.T) {
+
+	s := load(t, `{"Seed": 1234, "Fractal": 2}`)
+
+	if s.SessionData.Seed != 1234 {
+		t.Errorf("Seed = %d, want 1234", s.SessionData.Seed)
+	}
+	if s.SessionData.Fractal != simplexnoise.FractalRidged {
+		t.Errorf("Fractal = %v, want %v", s.SessionData.Fractal, simplexnoise.FractalRidged)
+	}
+}
@@ -0,0 +1,71 @@
+package simplexnoise
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Sampler64 returns a noise value for a point, e.g. a Generator's Noise64
+// or a closure over Fbm64 with fixed fractal parameters
+type Sampler64 func(x, y float64) float64
+
+// FillRow sets dst[i] to sample(x0+i*step, y). Each x is computed from its
+// index rather than accumulated, so long rows do not drift.
+func FillRow(dst []float64, x0, step, y float64, sample Sampler64) {
+	for i := range dst {
+		dst[i] = sample(x0+float64(i)*step, y)
+	}
+}
+
+// FillGrid sets dst[j][i] to sample(x0+i*xStep, y0+j*yStep), filling rows
+// on up to workers goroutines. A workers value below 1 uses GOMAXPROCS.
+// The sampler must be safe for concurrent use; Generator methods are.
+func FillGrid(dst [][]float64, x0, xStep, y0, yStep float64, workers int, sample Sampler64) {
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(dst) {
+		workers = len(dst)
+	}
+
+	if workers <= 1 {
+		for j := range dst {
+			FillRow(dst[j], x0, xStep, y0+float64(j)*yStep, sample)
+		}
+		return
+	}
+
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for j := range rows {
+				FillRow(dst[j], x0, xStep, y0+float64(j)*yStep, sample)
+			}
+		}()
+	}
+	for j := range dst {
+		rows <- j
+	}
+	close(rows)
+	wg.Wait()
+}
+
+// FbmRow fills dst with Fbm64 sampled along a row starting at x0
+func (g *Generator) FbmRow(dst []float64, x0, step, y, frequency, lacunarity, gain float64, octaves int) {
+	FillRow(dst, x0, step, y, g.fbmSampler(frequency, lacunarity, gain, octaves))
+}
+
+// FbmGrid fills dst with Fbm64 sampled over a grid, see FillGrid
+func (g *Generator) FbmGrid(dst [][]float64, x0, xStep, y0, yStep, frequency, lacunarity, gain float64, octaves, workers int) {
+	FillGrid(dst, x0, xStep, y0, yStep, workers, g.fbmSampler(frequency, lacunarity, gain, octaves))
+}
+
+func (g *Generator) fbmSampler(frequency, lacunarity, gain float64, octaves int) Sampler64 {
+	return func(x, y float64) float64 {
+		return g.Fbm64(x, y, frequency, lacunarity, gain, octaves)
+	}
+}
@@ -0,0 +1,80 @@
+package simplexnoise
+
+import "testing"
+
+const (
+	benchColumns = 64
+	benchRows    = 48
+)
+
+func newBenchGrid() [][]float64 {
+	dst := make([][]float64, benchRows)
+	for j := range dst {
+		dst[j] = make([]float64, benchColumns)
+	}
+	return dst
+}
+
+func TestFbmGridMatchesFbm64(t *testing.T) {
+
+	g := New(7)
+
+	for _, workers := range []int{1, 4} {
+		dst := newBenchGrid()
+		g.FbmGrid(dst, 999, 0.25, 3, 0.5, 0.1, 2, 0.5, 4, workers)
+		for j := range dst {
+			for i := range dst[j] {
+				want := g.Fbm64(999+float64(i)*0.25, 3+float64(j)*0.5, 0.1, 2, 0.5, 4)
+				if dst[j][i] != want {
+					t.Fatalf("workers %d: dst[%d][%d] = %v, want %v", workers, j, i, dst[j][i], want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkBatchRow(b *testing.B) {
+	g := New(7)
+	dst := make([]float64, benchColumns)
+	for n := 0; n < b.N; n++ {
+		g.FbmRow(dst, 999, 0.25, 3, 0.1, 2, 0.5, 4)
+	}
+}
+
+func BenchmarkBatchRowLoop(b *testing.B) {
+	g := New(7)
+	dst := make([]float64, benchColumns)
+	for n := 0; n < b.N; n++ {
+		for i := range dst {
+			dst[i] = g.Fbm64(999+float64(i)*0.25, 3, 0.1, 2, 0.5, 4)
+		}
+	}
+}
+
+func BenchmarkBatchGrid(b *testing.B) {
+	g := New(7)
+	dst := newBenchGrid()
+	for n := 0; n < b.N; n++ {
+		g.FbmGrid(dst, 999, 0.25, 3, 0.5, 0.1, 2, 0.5, 4, 0)
+	}
+}
+
+func BenchmarkBatchGridSerial(b *testing.B) {
+	g := New(7)
+	dst := newBenchGrid()
+	for n := 0; n < b.N; n++ {
+		g.FbmGrid(dst, 999, 0.25, 3, 0.5, 0.1, 2, 0.5, 4, 1)
+	}
+}
+
+func BenchmarkBatchGridLoop(b *testing.B) {
+	g := New(7)
+	dst := newBenchGrid()
+	for n := 0; n < b.N; n++ {
+		for j := range dst {
+			for i := range dst[j] {
+				dst[j][i] = g.Fbm64(999+float64(i)*0.25, 3+float64(j)*0.5, 0.1, 2, 0.5, 4)
+			}
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package simplexnoise
+
+// Float64 variants of the 2D, 3D and 4D noise. The float32 functions lose precision
+// once x grows into the hundreds (e.g. Offset=999), where the spacing between
+// representable values approaches the size of a single step. These keep the
+// same algorithm and permutation tables but carry all coordinates as float64.
+
+func fastFloor64(x float64) int {
+	if float64(int(x)) <= x {
+		return int(x)
+	}
+	return int(x) - 1
+}
+
+func grad2f64(hash uint8, x, y float64) float64 {
+	h := hash & 7
+	u := y
+	v := 2 * x
+	if h < 4 {
+		u = x
+		v = 2 * y
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+func grad3f64(hash uint8, x, y, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+func grad4f64(hash uint8, x, y, z, t float64) float64 {
+	h := hash & 31
+	u := y
+	if h < 24 {
+		u = x
+	}
+	v := z
+	if h < 16 {
+		v = y
+	}
+	w := t
+	if h < 8 {
+		w = z
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	if h&4 != 0 {
+		w = -w
+	}
+	return u + v + w
+}
+
+// Noise64 2D simplex noise at float64 precision using the reference permutation table
+func Noise64(x, y float64) float64 {
+	return defaultGenerator.Noise64(x, y)
+}
+
+// Noise3f64 3D simplex noise at float64 precision using the reference permutation table
+func Noise3f64(x, y, z float64) float64 {
+	return defaultGenerator.Noise3f64(x, y, z)
+}
+
+// Noise4f64 4D simplex noise at float64 precision using the reference permutation table
+func Noise4f64(x, y, z, w float64) float64 {
+	return defaultGenerator.Noise4f64(x, y, z, w)
+}
+
+// Fbm64 fractal noise at float64 precision using the reference permutation table
+func Fbm64(x, y, frequency, lacunarity, gain float64, octaves int) float64 {
+	return defaultGenerator.Fbm64(x, y, frequency, lacunarity, gain, octaves)
+}
+
+// Noise64 2D simplex noise at float64 precision.
+// Values match Noise, unscaled, to within float32 rounding.
+func (g *Generator) Noise64(x, y float64) float64 {
+
+	const F2 = 0.36602540378443864676 // F2 = 0.5*(sqrt(3.0)-1.0)
+	const G2 = 0.21132486540518711775 // G2 = (3.0-Math.sqrt(3.0))/6.0
+
+	var n0, n1, n2 float64
+
+	// Skew the input space to determine which simplex cell we're in
+	s := (x + y) * F2
+	i := fastFloor64(x + s)
+	j := fastFloor64(y + s)
+
+	t := float64(i+j) * G2
+	x0 := x - (float64(i) - t)
+	y0 := y - (float64(j) - t)
+
+	var i1, j1 uint8
+	if x0 > y0 {
+		i1 = 1
+	} else {
+		j1 = 1
+	}
+
+	x1 := x0 - float64(i1) + G2
+	y1 := y0 - float64(j1) + G2
+	x2 := x0 - 1.0 + 2.0*G2
+	y2 := y0 - 1.0 + 2.0*G2
+
+	ii := uint8(i)
+	jj := uint8(j)
+
+	t0 := 0.5 - x0*x0 - y0*y0
+	if t0 >= 0.0 {
+		t0 *= t0
+		n0 = t0 * t0 * grad2f64(g.perm[ii+g.perm[jj]], x0, y0)
+	}
+
+	t1 := 0.5 - x1*x1 - y1*y1
+	if t1 >= 0.0 {
+		t1 *= t1
+		n1 = t1 * t1 * grad2f64(g.perm[ii+i1+g.perm[jj+j1]], x1, y1)
+	}
+
+	t2 := 0.5 - x2*x2 - y2*y2
+	if t2 >= 0.0 {
+		t2 *= t2
+		n2 = t2 * t2 * grad2f64(g.perm[ii+1+g.perm[jj+1]], x2, y2)
+	}
+
+	return n0 + n1 + n2
+}
+
+// Noise3f64 3D simplex noise at float64 precision.
+// Values match Noise3 to within float32 rounding.
+func (g *Generator) Noise3f64(x, y, z float64) float64 {
+
+	const F3 = 1.0 / 3.0
+	const G3 = 1.0 / 6.0
+
+	var n0, n1, n2, n3 float64
+
+	// Skew the input space to determine which simplex cell we're in
+	s := (x + y + z) * F3
+	i := fastFloor64(x + s)
+	j := fastFloor64(y + s)
+	k := fastFloor64(z + s)
+
+	t := float64(i+j+k) * G3
+	x0 := x - (float64(i) - t)
+	y0 := y - (float64(j) - t)
+	z0 := z - (float64(k) - t)
+
+	var i1, j1, k1, i2, j2, k2 uint8
+	if x0 >= y0 {
+		if y0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		} else if x0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else {
+		if y0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		} else if x0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	x1 := x0 - float64(i1) + G3
+	y1 := y0 - float64(j1) + G3
+	z1 := z0 - float64(k1) + G3
+	x2 := x0 - float64(i2) + 2.0*G3
+	y2 := y0 - float64(j2) + 2.0*G3
+	z2 := z0 - float64(k2) + 2.0*G3
+	x3 := x0 - 1.0 + 3.0*G3
+	y3 := y0 - 1.0 + 3.0*G3
+	z3 := z0 - 1.0 + 3.0*G3
+
+	ii := uint8(i)
+	jj := uint8(j)
+	kk := uint8(k)
+
+	t0 := 0.6 - x0*x0 - y0*y0 - z0*z0
+	if t0 >= 0.0 {
+		t0 *= t0
+		n0 = t0 * t0 * grad3f64(g.perm[ii+g.perm[jj+g.perm[kk]]], x0, y0, z0)
+	}
+
+	t1 := 0.6 - x1*x1 - y1*y1 - z1*z1
+	if t1 >= 0.0 {
+		t1 *= t1
+		n1 = t1 * t1 * grad3f64(g.perm[ii+i1+g.perm[jj+j1+g.perm[kk+k1]]], x1, y1, z1)
+	}
+
+	t2 := 0.6 - x2*x2 - y2*y2 - z2*z2
+	if t2 >= 0.0 {
+		t2 *= t2
+		n2 = t2 * t2 * grad3f64(g.perm[ii+i2+g.perm[jj+j2+g.perm[kk+k2]]], x2, y2, z2)
+	}
+
+	t3 := 0.6 - x3*x3 - y3*y3 - z3*z3
+	if t3 >= 0.0 {
+		t3 *= t3
+		n3 = t3 * t3 * grad3f64(g.perm[ii+1+g.perm[jj+1+g.perm[kk+1]]], x3, y3, z3)
+	}
+
+	return 32.0 * (n0 + n1 + n2 + n3)
+}
+
+// Noise4f64 4D simplex noise at float64 precision.
+// Values match Noise4 to within float32 rounding.
+func (g *Generator) Noise4f64(x, y, z, w float64) float64 {
+
+	const F4 = 0.30901699437494742410 // F4 = (Math.sqrt(5.0)-1.0)/4.0
+	const G4 = 0.13819660112501051518 // G4 = (5.0-Math.sqrt(5.0))/20.0
+
+	var n0, n1, n2, n3, n4 float64
+
+	// Skew the input space to determine which simplex cell we're in
+	s := (x + y + z + w) * F4
+	i := fastFloor64(x + s)
+	j := fastFloor64(y + s)
+	k := fastFloor64(z + s)
+	l := fastFloor64(w + s)
+
+	t := float64(i+j+k+l) * G4
+	x0 := x - (float64(i) - t)
+	y0 := y - (float64(j) - t)
+	z0 := z - (float64(k) - t)
+	w0 := w - (float64(l) - t)
+
+	// Rank the coordinates to find the simplex, as in Noise4
+	c := 0
+	if x0 > y0 {
+		c += 32
+	}
+	if x0 > z0 {
+		c += 16
+	}
+	if y0 > z0 {
+		c += 8
+	}
+	if x0 > w0 {
+		c += 4
+	}
+	if y0 > w0 {
+		c += 2
+	}
+	if z0 > w0 {
+		c++
+	}
+
+	corner := func(rank, threshold uint8) uint8 {
+		if rank >= threshold {
+			return 1
+		}
+		return 0
+	}
+
+	i1 := corner(simplex[c][0], 3)
+	j1 := corner(simplex[c][1], 3)
+	k1 := corner(simplex[c][2], 3)
+	l1 := corner(simplex[c][3], 3)
+	i2 := corner(simplex[c][0], 2)
+	j2 := corner(simplex[c][1], 2)
+	k2 := corner(simplex[c][2], 2)
+	l2 := corner(simplex[c][3], 2)
+	i3 := corner(simplex[c][0], 1)
+	j3 := corner(simplex[c][1], 1)
+	k3 := corner(simplex[c][2], 1)
+	l3 := corner(simplex[c][3], 1)
+
+	x1 := x0 - float64(i1) + G4
+	y1 := y0 - float64(j1) + G4
+	z1 := z0 - float64(k1) + G4
+	w1 := w0 - float64(l1) + G4
+	x2 := x0 - float64(i2) + 2.0*G4
+	y2 := y0 - float64(j2) + 2.0*G4
+	z2 := z0 - float64(k2) + 2.0*G4
+	w2 := w0 - float64(l2) + 2.0*G4
+	x3 := x0 - float64(i3) + 3.0*G4
+	y3 := y0 - float64(j3) + 3.0*G4
+	z3 := z0 - float64(k3) + 3.0*G4
+	w3 := w0 - float64(l3) + 3.0*G4
+	x4 := x0 - 1.0 + 4.0*G4
+	y4 := y0 - 1.0 + 4.0*G4
+	z4 := z0 - 1.0 + 4.0*G4
+	w4 := w0 - 1.0 + 4.0*G4
+
+	ii := uint8(i)
+	jj := uint8(j)
+	kk := uint8(k)
+	ll := uint8(l)
+
+	t0 := 0.6 - x0*x0 - y0*y0 - z0*z0 - w0*w0
+	if t0 >= 0.0 {
+		t0 *= t0
+		n0 = t0 * t0 * grad4f64(g.perm[ii+g.perm[jj+g.perm[kk+g.perm[ll]]]], x0, y0, z0, w0)
+	}
+
+	t1 := 0.6 - x1*x1 - y1*y1 - z1*z1 - w1*w1
+	if t1 >= 0.0 {
+		t1 *= t1
+		n1 = t1 * t1 * grad4f64(g.perm[ii+i1+g.perm[jj+j1+g.perm[kk+k1+g.perm[ll+l1]]]], x1, y1, z1, w1)
+	}
+
+	t2 := 0.6 - x2*x2 - y2*y2 - z2*z2 - w2*w2
+	if t2 >= 0.0 {
+		t2 *= t2
+		n2 = t2 * t2 * grad4f64(g.perm[ii+i2+g.perm[jj+j2+g.perm[kk+k2+g.perm[ll+l2]]]], x2, y2, z2, w2)
+	}
+
+	t3 := 0.6 - x3*x3 - y3*y3 - z3*z3 - w3*w3
+	if t3 >= 0.0 {
+		t3 *= t3
+		n3 = t3 * t3 * grad4f64(g.perm[ii+i3+g.perm[jj+j3+g.perm[kk+k3+g.perm[ll+l3]]]], x3, y3, z3, w3)
+	}
+
+	t4 := 0.6 - x4*x4 - y4*y4 - z4*z4 - w4*w4
+	if t4 >= 0.0 {
+		t4 *= t4
+		n4 = t4 * t4 * grad4f64(g.perm[ii+1+g.perm[jj+1+g.perm[kk+1+g.perm[ll+1]]]], x4, y4, z4, w4)
+	}
+
+	return 27.0 * (n0 + n1 + n2 + n3 + n4)
+}
+
+// Fbm64 sums octaves of Noise64, scaling frequency by lacunarity
+// and amplitude by gain for each octave
+func (g *Generator) Fbm64(x, y, frequency, lacunarity, gain float64, octaves int) float64 {
+	sum := 0.0
+	amplitude := 1.0
+	for i := 0; i < octaves; i++ {
+		sum += g.Noise64(x*frequency, y*frequency) * amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
@@ -0,0 +1,80 @@
+package simplexnoise
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// The float64 variants run the same algorithm as the float32 functions, so
+// for small coordinates they agree to within float32 rounding. In 3D and 4D
+// the kernels reach past their simplex, which leaves small seams along the
+// simplex edges; a point that float32 rounding puts on the other side of a
+// seam may differ by up to the size of the seam.
+func TestFloat64MatchesFloat32(t *testing.T) {
+
+	const points = 100000
+	const tolerance = 2e-5
+	const seam = 2e-3
+
+	r := rand.New(rand.NewSource(1))
+	coord := func() float32 { return r.Float32()*64 - 32 }
+
+	tests := []struct {
+		name   string
+		single func(x, y, z, w float32) float32
+		double func(x, y, z, w float64) float64
+	}{
+		{
+			name:   "2D",
+			single: func(x, y, z, w float32) float32 { return Noise(x, y) },
+			double: func(x, y, z, w float64) float64 { return Noise64(x, y) },
+		},
+		{
+			name:   "3D",
+			single: func(x, y, z, w float32) float32 { return Noise3(x, y, z) },
+			double: func(x, y, z, w float64) float64 { return Noise3f64(x, y, z) },
+		},
+		{
+			name:   "4D",
+			single: func(x, y, z, w float32) float32 { return Noise4(x, y, z, w) },
+			double: func(x, y, z, w float64) float64 { return Noise4f64(x, y, z, w) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outside := 0
+			for i := 0; i < points; i++ {
+				x, y, z, w := coord(), coord(), coord(), coord()
+				single := float64(tt.single(x, y, z, w))
+				double := tt.double(float64(x), float64(y), float64(z), float64(w))
+				diff := math.Abs(single - double)
+				if diff > seam {
+					t.Fatalf("(%v, %v, %v, %v): float32 %v, float64 %v", x, y, z, w, single, double)
+				}
+				if diff > tolerance {
+					outside++
+				}
+			}
+			// Seams are crossed only by points within rounding of an edge
+			if outside > points/10000 {
+				t.Errorf("%d of %d points differ by more than %v", outside, points, tolerance)
+			}
+		})
+	}
+}
+
+// At large offsets float32 can no longer tell neighbouring steps apart,
+// while float64 still resolves them
+func TestFloat64LargeOffset(t *testing.T) {
+
+	const x0, step = 100000, 1.0 / 64
+
+	if float32(x0) != float32(x0+step/4) {
+		t.Fatalf("float32 resolves a quarter step at x = %v", x0)
+	}
+	if Noise64(x0, 0.5) == Noise64(x0+step/4, 0.5) {
+		t.Errorf("Noise64 does not resolve a quarter step at x = %v", x0)
+	}
+}
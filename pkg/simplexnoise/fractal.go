@@ -0,0 +1,239 @@
+package simplexnoise
+
+import "math"
+
+// Fractal selects how octaves of noise are combined
+type Fractal uint8
+
+const (
+	// FractalFbm is the original unnormalized sum of octaves. Its range grows
+	// with gain and octaves, roughly [-0.025, 0.025] * (1 + gain + gain^2 + ...)
+	FractalFbm Fractal = iota
+	// FractalNormalized is fBm divided by the total amplitude, in [-1, 1]
+	FractalNormalized
+	// FractalRidged is a ridged multifractal with sharp peaks, in [-1, 1]
+	FractalRidged
+	// FractalBillow folds each octave into rounded lobes, in [-1, 1]
+	FractalBillow
+	// FractalTurbulence sums the absolute value of each octave, in [0, 1]
+	FractalTurbulence
+	// FractalHybrid is a hybrid multifractal, smooth in valleys and rough on peaks, in [-1, 1]
+	FractalHybrid
+)
+
+// FractalNames are short labels for each Fractal, indexed by value
+var FractalNames = []string{"fbm", "norm", "ridged", "billow", "turb", "hybrid"}
+
+// noiseScale restores the reference scale factor that Noise leaves out,
+// bringing a single octave close to [-1, 1]
+const noiseScale float32 = 40.0
+
+// String returns the short label of the fractal
+func (f Fractal) String() string {
+	if int(f) < len(FractalNames) {
+		return FractalNames[f]
+	}
+	return "unknown"
+}
+
+// Range returns the documented output range of the fractal. FractalFbm is
+// unbounded, so the range its callers have always assumed is returned.
+func (f Fractal) Range() (min, max float32) {
+	if f == FractalTurbulence {
+		return 0, 1
+	}
+	return -1, 1
+}
+
+// Basis is a single octave of noise, roughly in [-1, 1]
+type Basis func(x, y float32) float32
+
+// FbmBasis scales b down by the factor that Noise leaves out, so FractalFbm
+// over b has the same range as FractalFbm over simplex noise
+func FbmBasis(b Basis) Basis {
+	return func(x, y float32) float32 {
+		return b(x, y) / noiseScale
+	}
+}
+
+// FractalNoise samples the fractal f using the reference permutation table
+func FractalNoise(f Fractal, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return defaultGenerator.FractalNoise(f, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// FractalNoise samples the fractal f at x,y
+func (g *Generator) FractalNoise(f Fractal, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	if f == FractalFbm {
+		return g.Fbm(x, y, frequency, lacunarity, gain, octaves)
+	}
+	return FractalBasis(g.basis, f, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// FbmNormalized samples FractalNormalized at x,y
+func (g *Generator) FbmNormalized(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return fbmNormalized(g.basis, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// Ridged samples FractalRidged at x,y
+func (g *Generator) Ridged(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return ridged(g.basis, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// Billow samples FractalBillow at x,y
+func (g *Generator) Billow(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return billow(g.basis, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// Turbulence samples FractalTurbulence at x,y
+func (g *Generator) Turbulence(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return turbulence(g.basis, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// Hybrid samples FractalHybrid at x,y
+func (g *Generator) Hybrid(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return hybrid(g.basis, x, y, frequency, lacunarity, gain, octaves)
+}
+
+// basis is a single octave of Noise scaled to about [-1, 1]
+func (g *Generator) basis(x, y float32) float32 {
+	return clamp(g.Noise(x, y)*noiseScale, -1, 1)
+}
+
+// octave samples a single octave of Noise scaled to about [-1, 1]
+func (g *Generator) octave(x, y, frequency float32) float32 {
+	return g.basis(x*frequency, y*frequency)
+}
+
+// FractalBasis combines octaves of any basis function using the fractal f.
+// FractalFbm sums the octaves without normalizing them.
+func FractalBasis(b Basis, f Fractal, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	switch f {
+	case FractalNormalized:
+		return fbmNormalized(b, x, y, frequency, lacunarity, gain, octaves)
+	case FractalRidged:
+		return ridged(b, x, y, frequency, lacunarity, gain, octaves)
+	case FractalBillow:
+		return billow(b, x, y, frequency, lacunarity, gain, octaves)
+	case FractalTurbulence:
+		return turbulence(b, x, y, frequency, lacunarity, gain, octaves)
+	case FractalHybrid:
+		return hybrid(b, x, y, frequency, lacunarity, gain, octaves)
+	default:
+		return fbm(b, x, y, frequency, lacunarity, gain, octaves)
+	}
+}
+
+// fbm sums octaves, scaling frequency by lacunarity and amplitude by gain
+func fbm(b Basis, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0)
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += b(x*frequency, y*frequency) * amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
+
+// fbmNormalized sums octaves like fbm, then divides by the total amplitude
+// so the result stays in [-1, 1] for any gain
+func fbmNormalized(b Basis, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0)
+	total := float32(0)
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += b(x*frequency, y*frequency) * amplitude
+		total += amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return normalize(sum, total)
+}
+
+// ridged inverts the absolute value of each octave to form sharp ridges,
+// weighting each octave by the one before it. The result is in [-1, 1].
+func ridged(b Basis, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0)
+	total := float32(0)
+	amplitude := float32(1)
+	weight := float32(1)
+	for i := 0; i < octaves; i++ {
+		signal := 1 - abs(b(x*frequency, y*frequency))
+		signal *= signal * weight
+		weight = clamp(signal, 0, 1)
+		sum += signal * amplitude
+		total += amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return normalize(sum, total)*2 - 1
+}
+
+// billow folds each octave with an absolute value, giving rounded,
+// bouncing contours. The result is in [-1, 1].
+func billow(b Basis, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0)
+	total := float32(0)
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += (abs(b(x*frequency, y*frequency))*2 - 1) * amplitude
+		total += amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return normalize(sum, total)
+}
+
+// turbulence sums the absolute value of each octave. The result is in [0, 1].
+func turbulence(b Basis, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0)
+	total := float32(0)
+	amplitude := float32(1)
+	for i := 0; i < octaves; i++ {
+		sum += abs(b(x*frequency, y*frequency)) * amplitude
+		total += amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return clamp(normalize(sum, total), 0, 1)
+}
+
+// hybrid scales each octave by the running value of the octaves before it,
+// so low areas stay smooth while high areas pick up detail. The result is in [-1, 1].
+func hybrid(b Basis, x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0)
+	total := float32(0)
+	amplitude := float32(1)
+	weight := float32(1)
+	for i := 0; i < octaves; i++ {
+		signal := (b(x*frequency, y*frequency) + 1) * 0.5
+		sum += weight * signal * amplitude
+		total += amplitude
+		weight = clamp(weight*signal*2, 0, 1)
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return normalize(sum, total)*2 - 1
+}
+
+func normalize(sum, total float32) float32 {
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+func abs(x float32) float32 {
+	return float32(math.Abs(float64(x)))
+}
+
+func clamp(x, low, high float32) float32 {
+	switch {
+	case x < low:
+		return low
+	case x > high:
+		return high
+	default:
+		return x
+	}
+}
@@ -0,0 +1,31 @@
+package simplexnoise
+
+import "math"
+
+// LoopNoise samples a looping fractal using the reference permutation table
+func LoopNoise(f Fractal, t, radius, y, w, frequency, lacunarity, gain float32, octaves int) float32 {
+	return defaultGenerator.LoopNoise(f, t, radius, y, w, frequency, lacunarity, gain, octaves)
+}
+
+// LoopNoise samples the fractal f around a circle of the given radius in 4D
+// noise, so t=1 flows seamlessly into t=0. Moving y evolves the loop and w
+// selects a different loop. FractalFbm is scaled down to match the range of Fbm.
+func (g *Generator) LoopNoise(f Fractal, t, radius, y, w, frequency, lacunarity, gain float32, octaves int) float32 {
+
+	angle := 2 * math.Pi * float64(t)
+	cos := float32(math.Cos(angle))
+	sin := float32(math.Sin(angle))
+
+	scale := float32(1)
+	if f == FractalFbm {
+		scale = 1 / noiseScale
+	}
+
+	// The fractal scales r and z by the frequency of each octave,
+	// which scales the whole circle
+	basis := func(r, z float32) float32 {
+		return g.Noise4(r*cos, r*sin, z, w) * scale
+	}
+
+	return FractalBasis(basis, f, radius, y, frequency, lacunarity, gain, octaves)
+}
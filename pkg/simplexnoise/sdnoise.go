@@ -0,0 +1,293 @@
+package simplexnoise
+
+/*
+ * Simplex noise with analytic derivatives, following the structure of
+ * Stefan Gustavson's sdnoise1234.c. The derivative variants use the same
+ * gradients as Noise1, Noise, Noise3 and Noise4, so the returned noise
+ * value is identical to the plain functions and the partial derivatives
+ * describe the slope of that same field.
+ *
+ * Each corner contributes n = t^4 * (g . d) where t = r - |d|^2, so the
+ * derivative along each axis is t^4 * g - 8 * t^3 * (g . d) * d.
+ */
+
+// Noise1Deriv 1D simplex noise and its derivative using the reference permutation table
+func Noise1Deriv(x float32) (n, dx float32) {
+	return defaultGenerator.Noise1Deriv(x)
+}
+
+// Noise2Deriv 2D simplex noise and its partial derivatives using the reference permutation table
+func Noise2Deriv(x, y float32) (n, dx, dy float32) {
+	return defaultGenerator.Noise2Deriv(x, y)
+}
+
+// Noise3Deriv 3D simplex noise and its partial derivatives using the reference permutation table
+func Noise3Deriv(x, y, z float32) (n, dx, dy, dz float32) {
+	return defaultGenerator.Noise3Deriv(x, y, z)
+}
+
+// Noise4Deriv 4D simplex noise and its partial derivatives using the reference permutation table
+func Noise4Deriv(x, y, z, w float32) (n, dx, dy, dz, dw float32) {
+	return defaultGenerator.Noise4Deriv(x, y, z, w)
+}
+
+// Noise1Deriv 1D simplex noise and its derivative
+func (g *Generator) Noise1Deriv(x float32) (n, dx float32) {
+
+	i0 := fastFloor(x)
+	i1 := i0 + 1
+	x0 := x - float32(i0)
+	x1 := x0 - 1.0
+
+	// The gradient is the dot product with a unit residual
+	gx0 := grad1(g.perm[uint8(i0)], 1)
+	gx1 := grad1(g.perm[uint8(i1)], 1)
+
+	t0 := 1.0 - x0*x0
+	t20 := t0 * t0
+	t40 := t20 * t20
+	n0 := t40 * gx0 * x0
+
+	t1 := 1.0 - x1*x1
+	t21 := t1 * t1
+	t41 := t21 * t21
+	n1 := t41 * gx1 * x1
+
+	dx = t40*gx0 - 8.0*t20*t0*gx0*x0*x0
+	dx += t41*gx1 - 8.0*t21*t1*gx1*x1*x1
+
+	// Same scale factor as Noise1
+	return 0.395 * (n0 + n1), 0.395 * dx
+}
+
+// Noise2Deriv 2D simplex noise and its partial derivatives
+func (g *Generator) Noise2Deriv(x, y float32) (n, dx, dy float32) {
+
+	const F2 float32 = 0.366025403 // F2 = 0.5*(sqrt(3.0)-1.0)
+	const G2 float32 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
+
+	// Skew the input space to determine which simplex cell we're in
+	s := (x + y) * F2
+	i := fastFloor(x + s)
+	j := fastFloor(y + s)
+
+	t := float32(i+j) * G2
+	x0 := x - (float32(i) - t) // The x,y distances from the cell origin
+	y0 := y - (float32(j) - t)
+
+	// Offsets for second (middle) corner of simplex in (i,j) coords
+	var i1, j1 uint8
+	if x0 > y0 {
+		i1 = 1
+	} else {
+		j1 = 1
+	}
+
+	x1 := x0 - float32(i1) + G2
+	y1 := y0 - float32(j1) + G2
+	x2 := x0 - 1.0 + 2.0*G2
+	y2 := y0 - 1.0 + 2.0*G2
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+
+	corners := [3]struct {
+		hash uint8
+		x, y float32
+	}{
+		{g.perm[ii+g.perm[jj]], x0, y0},
+		{g.perm[ii+i1+g.perm[jj+j1]], x1, y1},
+		{g.perm[ii+1+g.perm[jj+1]], x2, y2},
+	}
+
+	for _, c := range corners {
+		tc := 0.5 - c.x*c.x - c.y*c.y
+		if tc < 0.0 {
+			continue
+		}
+		t2 := tc * tc
+		t4 := t2 * t2
+		gx := grad2(c.hash, 1, 0)
+		gy := grad2(c.hash, 0, 1)
+		gdot := gx*c.x + gy*c.y
+		n += t4 * gdot
+		dx += t4*gx - 8.0*t2*tc*gdot*c.x
+		dy += t4*gy - 8.0*t2*tc*gdot*c.y
+	}
+
+	// Noise is left unscaled, matching Noise
+	return n, dx, dy
+}
+
+// Noise3Deriv 3D simplex noise and its partial derivatives
+func (g *Generator) Noise3Deriv(x, y, z float32) (n, dx, dy, dz float32) {
+
+	const F3 float32 = 0.333333333
+	const G3 float32 = 0.166666667
+
+	// Skew the input space to determine which simplex cell we're in
+	s := (x + y + z) * F3
+	i := fastFloor(x + s)
+	j := fastFloor(y + s)
+	k := fastFloor(z + s)
+
+	t := float32(i+j+k) * G3
+	x0 := x - (float32(i) - t) // The x,y,z distances from the cell origin
+	y0 := y - (float32(j) - t)
+	z0 := z - (float32(k) - t)
+
+	var i1, j1, k1 uint8 // Offsets for second corner of simplex in (i,j,k) coords
+	var i2, j2, k2 uint8 // Offsets for third corner of simplex in (i,j,k) coords
+
+	if x0 >= y0 {
+		if y0 >= z0 { // X Y Z order
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		} else if x0 >= z0 { // X Z Y order
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		} else { // Z X Y order
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else { // x0<y0
+		if y0 < z0 { // Z Y X order
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		} else if x0 < z0 { // Y Z X order
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		} else { // Y X Z order
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+	kk := uint8(k)
+
+	corners := [4]struct {
+		hash    uint8
+		x, y, z float32
+	}{
+		{g.perm[ii+g.perm[jj+g.perm[kk]]], x0, y0, z0},
+		{g.perm[ii+i1+g.perm[jj+j1+g.perm[kk+k1]]], x0 - float32(i1) + G3, y0 - float32(j1) + G3, z0 - float32(k1) + G3},
+		{g.perm[ii+i2+g.perm[jj+j2+g.perm[kk+k2]]], x0 - float32(i2) + 2.0*G3, y0 - float32(j2) + 2.0*G3, z0 - float32(k2) + 2.0*G3},
+		{g.perm[ii+1+g.perm[jj+1+g.perm[kk+1]]], x0 - 1.0 + 3.0*G3, y0 - 1.0 + 3.0*G3, z0 - 1.0 + 3.0*G3},
+	}
+
+	for _, c := range corners {
+		tc := 0.6 - c.x*c.x - c.y*c.y - c.z*c.z
+		if tc < 0.0 {
+			continue
+		}
+		t2 := tc * tc
+		t4 := t2 * t2
+		gx := grad3(c.hash, 1, 0, 0)
+		gy := grad3(c.hash, 0, 1, 0)
+		gz := grad3(c.hash, 0, 0, 1)
+		gdot := gx*c.x + gy*c.y + gz*c.z
+		n += t4 * gdot
+		dx += t4*gx - 8.0*t2*tc*gdot*c.x
+		dy += t4*gy - 8.0*t2*tc*gdot*c.y
+		dz += t4*gz - 8.0*t2*tc*gdot*c.z
+	}
+
+	// Same scale factor as Noise3
+	return 32.0 * n, 32.0 * dx, 32.0 * dy, 32.0 * dz
+}
+
+// Noise4Deriv 4D simplex noise and its partial derivatives
+func (g *Generator) Noise4Deriv(x, y, z, w float32) (n, dx, dy, dz, dw float32) {
+
+	const F4 float32 = 0.309016994 // F4 = (Math.sqrt(5.0)-1.0)/4.0
+	const G4 float32 = 0.138196601 // G4 = (5.0-Math.sqrt(5.0))/20.0
+
+	// Skew the (x,y,z,w) space to determine which cell of 24 simplices we're in
+	s := (x + y + z + w) * F4
+	i := fastFloor(x + s)
+	j := fastFloor(y + s)
+	k := fastFloor(z + s)
+	l := fastFloor(w + s)
+
+	t := float32(i+j+k+l) * G4
+	x0 := x - (float32(i) - t) // The x,y,z,w distances from the cell origin
+	y0 := y - (float32(j) - t)
+	z0 := z - (float32(k) - t)
+	w0 := w - (float32(l) - t)
+
+	// Magnitude ordering of x0, y0, z0 and w0, see Noise4
+	c := 0
+	if x0 > y0 {
+		c += 32
+	}
+	if x0 > z0 {
+		c += 16
+	}
+	if y0 > z0 {
+		c += 8
+	}
+	if x0 > w0 {
+		c += 4
+	}
+	if y0 > w0 {
+		c += 2
+	}
+	if z0 > w0 {
+		c++
+	}
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+	kk := uint8(k)
+	ll := uint8(l)
+
+	for corner := uint8(0); corner < 5; corner++ {
+
+		// Offsets of this corner in (i,j,k,l) coords, taken from the largest
+		// coordinate down; the first corner is the origin, the last is (1,1,1,1)
+		var io, jo, ko, lo uint8
+		if corner > 0 {
+			threshold := 4 - corner
+			if simplex[c][0] >= threshold {
+				io = 1
+			}
+			if simplex[c][1] >= threshold {
+				jo = 1
+			}
+			if simplex[c][2] >= threshold {
+				ko = 1
+			}
+			if simplex[c][3] >= threshold {
+				lo = 1
+			}
+		}
+
+		offset := float32(corner) * G4
+		cx := x0 - float32(io) + offset
+		cy := y0 - float32(jo) + offset
+		cz := z0 - float32(ko) + offset
+		cw := w0 - float32(lo) + offset
+
+		tc := 0.6 - cx*cx - cy*cy - cz*cz - cw*cw
+		if tc < 0.0 {
+			continue
+		}
+
+		hash := g.perm[ii+io+g.perm[jj+jo+g.perm[kk+ko+g.perm[ll+lo]]]]
+
+		t2 := tc * tc
+		t4 := t2 * t2
+		gx := grad4(hash, 1, 0, 0, 0)
+		gy := grad4(hash, 0, 1, 0, 0)
+		gz := grad4(hash, 0, 0, 1, 0)
+		gw := grad4(hash, 0, 0, 0, 1)
+		gdot := gx*cx + gy*cy + gz*cz + gw*cw
+		n += t4 * gdot
+		dx += t4*gx - 8.0*t2*tc*gdot*cx
+		dy += t4*gy - 8.0*t2*tc*gdot*cy
+		dz += t4*gz - 8.0*t2*tc*gdot*cz
+		dw += t4*gw - 8.0*t2*tc*gdot*cw
+	}
+
+	// Same scale factor as Noise4
+	return 27.0 * n, 27.0 * dx, 27.0 * dy, 27.0 * dz, 27.0 * dw
+}
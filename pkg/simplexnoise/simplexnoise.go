@@ -1,5 +1,7 @@
 package simplexnoise
 
+import "math/rand"
+
 /* This code ported to Go from Stefan Gustavson's C implementation, his comments follow:
  * https://github.com/stegu/perlin-noise/blob/master/src/simplexnoise1234.c
  * SimplexNoise1234, Simplex noise with true analytic
@@ -64,6 +66,28 @@ var perm = [256]uint8{151, 160, 137, 91, 90, 15,
 
 //---------------------------------------------------------------------
 
+/*
+ * Helper functions to compute gradients-dot-residualvectors (1D to 4D)
+ * Note that these generate gradients of more than unit length. To make
+ * a close match with the value range of classic Perlin noise, the final
+ * noise values need to be rescaled to fit nicely within [-1,1].
+ * (The simplex noise functions as such also have different scaling.)
+ * Note also that these noise functions are the most practical and useful
+ * signed version of Perlin noise. To return values according to the
+ * RenderMan specification from the SL noise() and pnoise() functions,
+ * the noise values need to be scaled and offset to [0,1], like this:
+ * float SLnoise = (noise(x,y,z) + 1.0) * 0.5;
+ */
+
+func grad1(hash uint8, x float32) float32 {
+	h := hash & 15
+	grad := 1.0 + float32(h&7) // Gradient value 1.0, 2.0, ..., 8.0
+	if h&8 != 0 {
+		grad = -grad // Set a random sign for the gradient
+	}
+	return grad * x // Multiply the gradient with the distance
+}
+
 func grad2(hash uint8, x, y float32) float32 {
 	h := hash & 7 // Convert low 3 bits of hash code
 	u := y
@@ -83,8 +107,145 @@ func grad2(hash uint8, x, y float32) float32 {
 	return u + v
 }
 
-// Noise 2D simplex noise
+func grad3(hash uint8, x, y, z float32) float32 {
+	h := hash & 15 // Convert low 4 bits of hash code into 12 simple
+	u := y         // gradient directions, and compute dot product.
+	if h < 8 {
+		u = x
+	}
+	v := z // Fix repeats at h = 12 to 15
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+func grad4(hash uint8, x, y, z, t float32) float32 {
+	h := hash & 31 // Convert low 5 bits of hash code into 32 simple
+	u := y         // gradient directions, and compute dot product.
+	if h < 24 {
+		u = x
+	}
+	v := z
+	if h < 16 {
+		v = y
+	}
+	w := t
+	if h < 8 {
+		w = z
+	}
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	if h&4 != 0 {
+		w = -w
+	}
+	return u + v + w
+}
+
+// A lookup table to traverse the simplex around a given point in 4D.
+// Details can be found where this table is used, in the 4D noise method.
+var simplex = [64][4]uint8{
+	{0, 1, 2, 3}, {0, 1, 3, 2}, {0, 0, 0, 0}, {0, 2, 3, 1}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {1, 2, 3, 0},
+	{0, 2, 1, 3}, {0, 0, 0, 0}, {0, 3, 1, 2}, {0, 3, 2, 1}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {1, 3, 2, 0},
+	{0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0},
+	{1, 2, 0, 3}, {0, 0, 0, 0}, {1, 3, 0, 2}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {2, 3, 0, 1}, {2, 3, 1, 0},
+	{1, 0, 2, 3}, {1, 0, 3, 2}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {2, 0, 3, 1}, {0, 0, 0, 0}, {2, 1, 3, 0},
+	{0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0},
+	{2, 0, 1, 3}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {3, 0, 1, 2}, {3, 0, 2, 1}, {0, 0, 0, 0}, {3, 1, 2, 0},
+	{2, 1, 0, 3}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {3, 1, 0, 2}, {0, 0, 0, 0}, {3, 2, 0, 1}, {3, 2, 1, 0},
+}
+
+// Generator produces simplex noise from its own permutation table,
+// so generators built from different seeds yield different noise fields
+type Generator struct {
+	Seed int64
+	perm [256]uint8
+}
+
+// defaultGenerator backs the package-level functions
+var defaultGenerator = &Generator{perm: perm}
+
+// New returns a Generator whose permutation table is shuffled from seed.
+// A seed of 0 keeps the reference table, matching the package-level functions.
+func New(seed int64) *Generator {
+
+	g := &Generator{
+		Seed: seed,
+		perm: perm,
+	}
+
+	if seed != 0 {
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(g.perm), func(i, j int) {
+			g.perm[i], g.perm[j] = g.perm[j], g.perm[i]
+		})
+	}
+
+	return g
+}
+
+// Noise 2D simplex noise using the reference permutation table
 func Noise(x, y float32) float32 {
+	return defaultGenerator.Noise(x, y)
+}
+
+// Noise1 1D simplex noise using the reference permutation table
+func Noise1(x float32) float32 {
+	return defaultGenerator.Noise1(x)
+}
+
+// Noise3 3D simplex noise using the reference permutation table
+func Noise3(x, y, z float32) float32 {
+	return defaultGenerator.Noise3(x, y, z)
+}
+
+// Noise4 4D simplex noise using the reference permutation table
+func Noise4(x, y, z, w float32) float32 {
+	return defaultGenerator.Noise4(x, y, z, w)
+}
+
+// Fbm fractal noise using the reference permutation table
+func Fbm(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	return defaultGenerator.Fbm(x, y, frequency, lacunarity, gain, octaves)
+}
+
+// Noise1 1D simplex noise
+func (g *Generator) Noise1(x float32) float32 {
+
+	i0 := fastFloor(x)
+	i1 := i0 + 1
+	x0 := x - float32(i0)
+	x1 := x0 - 1.0
+
+	t0 := 1.0 - x0*x0
+	t0 *= t0
+	n0 := t0 * t0 * grad1(g.perm[uint8(i0)], x0)
+
+	t1 := 1.0 - x1*x1
+	t1 *= t1
+	n1 := t1 * t1 * grad1(g.perm[uint8(i1)], x1)
+
+	// The maximum value of this noise is 8*(3/4)^4 = 2.53125
+	// A factor of 0.395 scales to fit exactly within [-1,1]
+	return 0.395 * (n0 + n1)
+}
+
+// Noise 2D simplex noise
+func (g *Generator) Noise(x, y float32) float32 {
 
 	const F2 float32 = 0.366025403 // F2 = 0.5*(sqrt(3.0)-1.0)
 	const G2 float32 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
@@ -134,7 +295,7 @@ func Noise(x, y float32) float32 {
 		n0 = 0.0
 	} else {
 		t0 *= t0
-		n0 = t0 * t0 * grad2(perm[ii+perm[jj]], x0, y0)
+		n0 = t0 * t0 * grad2(g.perm[ii+g.perm[jj]], x0, y0)
 	}
 
 	t1 := 0.5 - x1*x1 - y1*y1
@@ -142,7 +303,7 @@ func Noise(x, y float32) float32 {
 		n1 = 0.0
 	} else {
 		t1 *= t1
-		n1 = t1 * t1 * grad2(perm[ii+i1+perm[jj+j1]], x1, y1)
+		n1 = t1 * t1 * grad2(g.perm[ii+i1+g.perm[jj+j1]], x1, y1)
 	}
 
 	t2 := 0.5 - x2*x2 - y2*y2
@@ -150,19 +311,282 @@ func Noise(x, y float32) float32 {
 		n2 = 0.0
 	} else {
 		t2 *= t2
-		n2 = t2 * t2 * grad2(perm[ii+1+perm[jj+1]], x2, y2)
+		n2 = t2 * t2 * grad2(g.perm[ii+1+g.perm[jj+1]], x2, y2)
 	}
 
 	// Add contributions from each corner to get the final noise value.
 	return (n0 + n1 + n2)
 }
 
-// Fbm ...
-func Fbm(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+// Noise3 3D simplex noise
+func (g *Generator) Noise3(x, y, z float32) float32 {
+
+	// Simple skewing factors for the 3D case
+	const F3 float32 = 0.333333333
+	const G3 float32 = 0.166666667
+
+	var n0, n1, n2, n3 float32 // Noise contributions from the four corners
+
+	// Skew the input space to determine which simplex cell we're in
+	s := (x + y + z) * F3 // Very nice and simple skew factor for 3D
+	xs := x + s
+	ys := y + s
+	zs := z + s
+	i := fastFloor(xs)
+	j := fastFloor(ys)
+	k := fastFloor(zs)
+
+	t := float32(i+j+k) * G3
+	X0 := float32(i) - t // Unskew the cell origin back to (x,y,z) space
+	Y0 := float32(j) - t
+	Z0 := float32(k) - t
+	x0 := x - X0 // The x,y,z distances from the cell origin
+	y0 := y - Y0
+	z0 := z - Z0
+
+	// For the 3D case, the simplex shape is a slightly irregular tetrahedron.
+	// Determine which simplex we are in.
+	var i1, j1, k1 uint8 // Offsets for second corner of simplex in (i,j,k) coords
+	var i2, j2, k2 uint8 // Offsets for third corner of simplex in (i,j,k) coords
+
+	if x0 >= y0 {
+		if y0 >= z0 { // X Y Z order
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		} else if x0 >= z0 { // X Z Y order
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		} else { // Z X Y order
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else { // x0<y0
+		if y0 < z0 { // Z Y X order
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		} else if x0 < z0 { // Y Z X order
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		} else { // Y X Z order
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	// A step of (1,0,0) in (i,j,k) means a step of (1-c,-c,-c) in (x,y,z),
+	// a step of (0,1,0) in (i,j,k) means a step of (-c,1-c,-c) in (x,y,z), and
+	// a step of (0,0,1) in (i,j,k) means a step of (-c,-c,1-c) in (x,y,z), where
+	// c = 1/6.
+
+	x1 := x0 - float32(i1) + G3 // Offsets for second corner in (x,y,z) coords
+	y1 := y0 - float32(j1) + G3
+	z1 := z0 - float32(k1) + G3
+	x2 := x0 - float32(i2) + 2.0*G3 // Offsets for third corner in (x,y,z) coords
+	y2 := y0 - float32(j2) + 2.0*G3
+	z2 := z0 - float32(k2) + 2.0*G3
+	x3 := x0 - 1.0 + 3.0*G3 // Offsets for last corner in (x,y,z) coords
+	y3 := y0 - 1.0 + 3.0*G3
+	z3 := z0 - 1.0 + 3.0*G3
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+	kk := uint8(k)
+
+	// Calculate the contribution from the four corners
+	t0 := 0.6 - x0*x0 - y0*y0 - z0*z0
+	if t0 < 0.0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad3(g.perm[ii+g.perm[jj+g.perm[kk]]], x0, y0, z0)
+	}
+
+	t1 := 0.6 - x1*x1 - y1*y1 - z1*z1
+	if t1 < 0.0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad3(g.perm[ii+i1+g.perm[jj+j1+g.perm[kk+k1]]], x1, y1, z1)
+	}
+
+	t2 := 0.6 - x2*x2 - y2*y2 - z2*z2
+	if t2 < 0.0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad3(g.perm[ii+i2+g.perm[jj+j2+g.perm[kk+k2]]], x2, y2, z2)
+	}
+
+	t3 := 0.6 - x3*x3 - y3*y3 - z3*z3
+	if t3 < 0.0 {
+		n3 = 0.0
+	} else {
+		t3 *= t3
+		n3 = t3 * t3 * grad3(g.perm[ii+1+g.perm[jj+1+g.perm[kk+1]]], x3, y3, z3)
+	}
+
+	// Add contributions from each corner to get the final noise value.
+	// The result is scaled to stay just inside [-1,1]
+	return 32.0 * (n0 + n1 + n2 + n3)
+}
+
+// Noise4 4D simplex noise
+func (g *Generator) Noise4(x, y, z, w float32) float32 {
+
+	// The skewing and unskewing factors are hairy again for the 4D case
+	const F4 float32 = 0.309016994 // F4 = (Math.sqrt(5.0)-1.0)/4.0
+	const G4 float32 = 0.138196601 // G4 = (5.0-Math.sqrt(5.0))/20.0
+
+	var n0, n1, n2, n3, n4 float32 // Noise contributions from the five corners
+
+	// Skew the (x,y,z,w) space to determine which cell of 24 simplices we're in
+	s := (x + y + z + w) * F4 // Factor for 4D skewing
+	xs := x + s
+	ys := y + s
+	zs := z + s
+	ws := w + s
+	i := fastFloor(xs)
+	j := fastFloor(ys)
+	k := fastFloor(zs)
+	l := fastFloor(ws)
+
+	t := float32(i+j+k+l) * G4 // Factor for 4D unskewing
+	X0 := float32(i) - t       // Unskew the cell origin back to (x,y,z,w) space
+	Y0 := float32(j) - t
+	Z0 := float32(k) - t
+	W0 := float32(l) - t
+
+	x0 := x - X0 // The x,y,z,w distances from the cell origin
+	y0 := y - Y0
+	z0 := z - Z0
+	w0 := w - W0
+
+	// For the 4D case, the simplex is a 4D shape I won't even try to describe.
+	// To find out which of the 24 possible simplices we're in, we need to
+	// determine the magnitude ordering of x0, y0, z0 and w0.
+	// The method below is a good way of finding the ordering of x,y,z,w and
+	// then find the correct traversal order for the simplex we’re in.
+	// First, six pair-wise comparisons are performed between each possible pair
+	// of the four coordinates, and the results are used to add up binary bits
+	// for an integer index.
+	c := 0
+	if x0 > y0 {
+		c += 32
+	}
+	if x0 > z0 {
+		c += 16
+	}
+	if y0 > z0 {
+		c += 8
+	}
+	if x0 > w0 {
+		c += 4
+	}
+	if y0 > w0 {
+		c += 2
+	}
+	if z0 > w0 {
+		c++
+	}
+
+	// simplex[c] is a 4-vector with the numbers 0, 1, 2 and 3 in some order.
+	// Many values of c will never occur, since e.g. x>y>z>w makes x<z, y<w and x<w
+	// impossible. Only the 24 indices which have non-zero entries make any sense.
+	// We use a thresholding to set the coordinates in turn from the largest magnitude.
+	corner := func(rank, threshold uint8) uint8 {
+		if rank >= threshold {
+			return 1
+		}
+		return 0
+	}
+
+	// The number 3 in the "simplex" array is at the position of the largest coordinate.
+	i1 := corner(simplex[c][0], 3)
+	j1 := corner(simplex[c][1], 3)
+	k1 := corner(simplex[c][2], 3)
+	l1 := corner(simplex[c][3], 3)
+	// The number 2 in the "simplex" array is at the second largest coordinate.
+	i2 := corner(simplex[c][0], 2)
+	j2 := corner(simplex[c][1], 2)
+	k2 := corner(simplex[c][2], 2)
+	l2 := corner(simplex[c][3], 2)
+	// The number 1 in the "simplex" array is at the second smallest coordinate.
+	i3 := corner(simplex[c][0], 1)
+	j3 := corner(simplex[c][1], 1)
+	k3 := corner(simplex[c][2], 1)
+	l3 := corner(simplex[c][3], 1)
+	// The fifth corner has all coordinate offsets = 1, so no need to look that up.
+
+	x1 := x0 - float32(i1) + G4 // Offsets for second corner in (x,y,z,w) coords
+	y1 := y0 - float32(j1) + G4
+	z1 := z0 - float32(k1) + G4
+	w1 := w0 - float32(l1) + G4
+	x2 := x0 - float32(i2) + 2.0*G4 // Offsets for third corner in (x,y,z,w) coords
+	y2 := y0 - float32(j2) + 2.0*G4
+	z2 := z0 - float32(k2) + 2.0*G4
+	w2 := w0 - float32(l2) + 2.0*G4
+	x3 := x0 - float32(i3) + 3.0*G4 // Offsets for fourth corner in (x,y,z,w) coords
+	y3 := y0 - float32(j3) + 3.0*G4
+	z3 := z0 - float32(k3) + 3.0*G4
+	w3 := w0 - float32(l3) + 3.0*G4
+	x4 := x0 - 1.0 + 4.0*G4 // Offsets for last corner in (x,y,z,w) coords
+	y4 := y0 - 1.0 + 4.0*G4
+	z4 := z0 - 1.0 + 4.0*G4
+	w4 := w0 - 1.0 + 4.0*G4
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := uint8(i)
+	jj := uint8(j)
+	kk := uint8(k)
+	ll := uint8(l)
+
+	// Calculate the contribution from the five corners
+	t0 := 0.6 - x0*x0 - y0*y0 - z0*z0 - w0*w0
+	if t0 < 0.0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad4(g.perm[ii+g.perm[jj+g.perm[kk+g.perm[ll]]]], x0, y0, z0, w0)
+	}
+
+	t1 := 0.6 - x1*x1 - y1*y1 - z1*z1 - w1*w1
+	if t1 < 0.0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad4(g.perm[ii+i1+g.perm[jj+j1+g.perm[kk+k1+g.perm[ll+l1]]]], x1, y1, z1, w1)
+	}
+
+	t2 := 0.6 - x2*x2 - y2*y2 - z2*z2 - w2*w2
+	if t2 < 0.0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad4(g.perm[ii+i2+g.perm[jj+j2+g.perm[kk+k2+g.perm[ll+l2]]]], x2, y2, z2, w2)
+	}
+
+	t3 := 0.6 - x3*x3 - y3*y3 - z3*z3 - w3*w3
+	if t3 < 0.0 {
+		n3 = 0.0
+	} else {
+		t3 *= t3
+		n3 = t3 * t3 * grad4(g.perm[ii+i3+g.perm[jj+j3+g.perm[kk+k3+g.perm[ll+l3]]]], x3, y3, z3, w3)
+	}
+
+	t4 := 0.6 - x4*x4 - y4*y4 - z4*z4 - w4*w4
+	if t4 < 0.0 {
+		n4 = 0.0
+	} else {
+		t4 *= t4
+		n4 = t4 * t4 * grad4(g.perm[ii+1+g.perm[jj+1+g.perm[kk+1+g.perm[ll+1]]]], x4, y4, z4, w4)
+	}
+
+	// Sum up and scale the result to cover the range [-1,1]
+	return 27.0 * (n0 + n1 + n2 + n3 + n4)
+}
+
+// Fbm sums octaves of Noise, scaling frequency by lacunarity
+// and amplitude by gain for each octave
+func (g *Generator) Fbm(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
 	sum := float32(0)
 	amplitude := float32(1)
 	for i := 0; i < octaves; i++ {
-		sum += Noise(x*frequency, y*frequency) * amplitude
+		sum += g.Noise(x*frequency, y*frequency) * amplitude
 		frequency *= lacunarity
 		amplitude *= gain
 	}
@@ -0,0 +1,38 @@
+package simplexnoise
+
+import "math"
+
+// warpOffset moves the warp field away from the field it displaces,
+// so the two are not correlated
+const warpOffset float32 = 5.2
+
+// WarpX displaces x using the reference permutation table
+func WarpX(x, y, frequency, amount float32) float32 {
+	return defaultGenerator.WarpX(x, y, frequency, amount)
+}
+
+// WarpX displaces x by a second noise field sampled at frequency and
+// scaled by amount. Sampling a fractal at the returned coordinate gives
+// domain-warped noise; an amount of 0 leaves x unchanged.
+func (g *Generator) WarpX(x, y, frequency, amount float32) float32 {
+	if amount == 0 {
+		return x
+	}
+	return x + g.octave(x+warpOffset, y+warpOffset, frequency)*amount
+}
+
+// WarpLoop displaces a loop position t using the reference permutation table
+func WarpLoop(t, radius, y, frequency, amount float32) float32 {
+	return defaultGenerator.WarpLoop(t, radius, y, frequency, amount)
+}
+
+// WarpLoop displaces a loop position t in [0, 1) by a second noise field
+// sampled around the same loop, so the warped loop stays seamless. amount is
+// in the same units as WarpX, measured along the circumference.
+func (g *Generator) WarpLoop(t, radius, y, frequency, amount float32) float32 {
+	if amount == 0 || radius == 0 {
+		return t
+	}
+	displacement := g.LoopNoise(FractalNormalized, t, radius, y+warpOffset, warpOffset, frequency, 1, 1, 1) * amount
+	return t + displacement/(2*math.Pi*radius)
+}
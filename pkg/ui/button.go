@@ -13,6 +13,7 @@ type Button struct {
 	Rect      pixel.Rect
 	Label     string
 	isGrouped bool
+	isTabbed  bool
 	isPressed bool
 	isEngaged bool
 }
@@ -44,6 +45,12 @@ func (b *Button) Compose() {
 		b.Imd.Rectangle(0)
 	}
 
+	if b.isTabbed && b.isEngaged {
+		b.Imd.Color = color.RGBA{0x36, 0xaf, 0xcf, 0xff}
+		b.Imd.Push(pixel.V(b.Rect.Min.X, b.Rect.Max.Y-4), b.Rect.Max)
+		b.Imd.Rectangle(0)
+	}
+
 	if b.isGrouped {
 		b.Imd.Color = color.RGBA{0xee, 0xee, 0xee, 0xff}
 		if b.isEngaged {
@@ -63,6 +70,11 @@ func (b *Button) SetGrouped(state bool) {
 	b.Compose()
 }
 
+func (b *Button) SetTabbed(state bool) {
+	b.isTabbed = state
+	b.Compose()
+}
+
 func (b *Button) SetPressed(state bool) {
 	b.isPressed = state
 	b.Compose()
@@ -4,14 +4,19 @@ import (
 	"fmt"
 	"image/color"
 	"log"
+	"math"
 	"strconv"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
 	"github.com/faiface/pixel/pixelgl"
+	"github.com/willgarrison/go-noise/pkg/generators"
 	"github.com/willgarrison/go-noise/pkg/helpers"
+	"github.com/willgarrison/go-noise/pkg/metronome"
+	"github.com/willgarrison/go-noise/pkg/noise"
 	"github.com/willgarrison/go-noise/pkg/session"
 	"github.com/willgarrison/go-noise/pkg/signals"
+	"github.com/willgarrison/go-noise/pkg/simplexnoise"
 )
 
 type Controls struct {
@@ -20,10 +25,13 @@ type Controls struct {
 	Dials               []*Dial
 	Buttons             []*Button
 	ModeButtons         []*Button
+	PageButtons         []*Button
+	Page                int
 	Imd                 *imdraw.IMDraw
 	ImdBatch            *imdraw.IMDraw
 	Typ                 *Typography
 	InputSessionChannel chan signals.Signal
+	InputGridChannel    chan signals.Signal
 	OutputChannels      []chan signals.Signal
 	SessionData         *session.SessionData
 }
@@ -47,6 +55,9 @@ func NewControls(r pixel.Rect, sessionData *session.SessionData) *Controls {
 	c.InputSessionChannel = make(chan signals.Signal)
 	c.ListenToInputSessionChannel()
 
+	c.InputGridChannel = make(chan signals.Signal)
+	c.ListenToInputGridChannel()
+
 	c.Typ = NewTypography()
 
 	return c
@@ -91,9 +102,13 @@ func (c *Controls) InitButtons() {
 	}
 
 	c.Buttons = []*Button{
-		NewButton("reset", pixel.R(columnPos[0], c.Rect.Min.Y+20, c.Rect.Max.X-20, c.Rect.Min.Y+90)),
+		NewButton("reset", pixel.R(columnPos[0], c.Rect.Min.Y+20, c.Rect.Max.X-20, c.Rect.Min.Y+80)),
 		NewButton("play", pixel.R(columnPos[0], rowPos[4], columnPos[0]+buttonWidths[1], rowPos[4]+buttonHeights[2])),
 		NewButton("stop", pixel.R(columnPos[2], rowPos[4], columnPos[2]+buttonWidths[0], rowPos[4]+buttonHeights[1])),
+		NewButton("train", pixel.R(columnPos[2], rowPos[5], columnPos[2]+buttonWidths[0], rowPos[5]+buttonHeights[0])),
+		NewButton("rules", pixel.R(columnPos[2]+buttonWidths[0]+5, rowPos[5], c.Rect.Max.X-20, rowPos[5]+buttonHeights[0])),
+		NewButton("text", pixel.R(columnPos[2], rowPos[6], columnPos[2]+buttonWidths[0], rowPos[6]+buttonHeights[1])),
+		NewButton("midi", pixel.R(columnPos[2]+buttonWidths[0]+5, rowPos[6], c.Rect.Max.X-20, rowPos[6]+buttonHeights[1])),
 		NewButton("save", pixel.R(columnPos[0], rowPos[7], columnPos[0]+buttonWidths[1], rowPos[7]+buttonHeights[1])),
 		NewButton("load", pixel.R(columnPos[2], rowPos[7], columnPos[2]+buttonWidths[1], rowPos[7]+buttonHeights[1])),
 	}
@@ -103,6 +118,19 @@ func (c *Controls) InitButtons() {
 	}
 
 	c.ModeButtons[0].SetEngaged(true)
+
+	// Page tabs select which set of dials is shown
+	pageLabels := []string{"main", "noise", "dyn", "mod", "rhy", "poly", "gen"}
+	pageWidth := (c.W - 40) / float64(len(pageLabels))
+
+	c.PageButtons = make([]*Button, len(pageLabels))
+	for i, label := range pageLabels {
+		x := columnPos[0] + float64(i)*pageWidth
+		c.PageButtons[i] = NewButton(label, pixel.R(x, c.Rect.Min.Y+90, x+pageWidth, c.Rect.Min.Y+120))
+		c.PageButtons[i].SetTabbed(true)
+	}
+
+	c.PageButtons[0].SetEngaged(true)
 }
 
 func (c *Controls) InitDials() {
@@ -124,7 +152,7 @@ func (c *Controls) InitDials() {
 		c.Rect.Min.Y + 490,
 	}
 
-	c.Dials = make([]*Dial, 14)
+	c.Dials = make([]*Dial, 77)
 	c.Dials[0] = NewDial("freq", "%.3f", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), c.SessionData.Frequency, 0.01, 3.0, 0.001)
 	c.Dials[1] = NewDial("space", "%.2f", pixel.R(columnPos[1], rowPos[0], columnPos[1]+dialWidth, rowPos[0]+dialHeight), c.SessionData.Lacunarity, 0.01, 3.0, 0.01)
 	c.Dials[2] = NewDial("gain", "%.1f", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), c.SessionData.Gain, 0.01, 3.0, 0.1)
@@ -140,6 +168,149 @@ func (c *Controls) InitDials() {
 	c.Dials[11] = NewDial("k", "%.0f", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.K), 1, 32, 1)
 	c.Dials[12] = NewDial("r", "%.0f", pixel.R(columnPos[2], rowPos[2], columnPos[2]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.R), 0, 32, 1)
 	c.Dials[13] = NewDial("g", "%.0f", pixel.R(columnPos[2], rowPos[3], columnPos[2]+dialWidth, rowPos[3]+dialHeight), float64(c.SessionData.G), 0, 32, 1)
+	// Noise Seed Dial
+	c.Dials[14] = NewDial("seed", "%.0f", pixel.R(columnPos[2], rowPos[4], columnPos[2]+dialWidth, rowPos[4]+dialHeight), float64(c.SessionData.Seed), 0, 9999, 1)
+	// Noise Page Dials
+	c.Dials[15] = NewSelector("fractal", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), simplexnoise.FractalNames, float64(c.SessionData.Fractal))
+	c.Dials[15].Page = 1
+	c.Dials[16] = NewDial("warp", "%.1f", pixel.R(columnPos[1], rowPos[0], columnPos[1]+dialWidth, rowPos[0]+dialHeight), c.SessionData.WarpAmount, 0, 32, 0.1)
+	c.Dials[16].Page = 1
+	c.Dials[17] = NewDial("wfreq", "%.3f", pixel.R(columnPos[2], rowPos[0], columnPos[2]+dialWidth, rowPos[0]+dialHeight), c.SessionData.WarpFrequency, 0.01, 3.0, 0.001)
+	c.Dials[17].Page = 1
+	c.Dials[18] = NewSelector("source", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), noise.SourceNames, float64(c.SessionData.Source))
+	c.Dials[18].Page = 1
+	c.Dials[19] = NewDial("drift", "%.3f", pixel.R(columnPos[1], rowPos[1], columnPos[1]+dialWidth, rowPos[1]+dialHeight), c.SessionData.Drift, 0, 1, 0.001)
+	c.Dials[19].Page = 1
+	c.Dials[20] = NewSelector("loop", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), []string{"off", "on"}, helpers.BoolToFloat64(c.SessionData.Loop))
+	c.Dials[20].Page = 1
+	c.Dials[21] = NewDial("voices", "%.0f", pixel.R(columnPos[0], rowPos[2], columnPos[0]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.Voices), 1, 4, 1)
+	c.Dials[21].Page = 1
+	c.Dials[22] = NewDial("spread", "%.1f", pixel.R(columnPos[1], rowPos[2], columnPos[1]+dialWidth, rowPos[2]+dialHeight), c.SessionData.VoiceSpread, 0, 100, 0.1)
+	c.Dials[22].Page = 1
+	c.Dials[23] = NewSelector("harmony", pixel.R(columnPos[2], rowPos[2], columnPos[2]+dialWidth, rowPos[2]+dialHeight), VoiceModeNames, float64(c.SessionData.VoiceMode))
+	c.Dials[23].Page = 1
+	c.Dials[24] = NewDial("interval", "%.0f", pixel.R(columnPos[2], rowPos[3], columnPos[2]+dialWidth, rowPos[3]+dialHeight), float64(c.SessionData.VoiceInterval), 1, 12, 1)
+	c.Dials[24].Page = 1
+	// Dynamics Page Dials
+	c.Dials[25] = NewSelector("vel", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), VelocityModeNames, float64(c.SessionData.VelocityMode))
+	c.Dials[25].Page = 2
+	c.Dials[26] = NewDial("vfix", "%.0f", pixel.R(columnPos[1], rowPos[0], columnPos[1]+dialWidth, rowPos[0]+dialHeight), float64(c.SessionData.Velocity), 1, 127, 1)
+	c.Dials[26].Page = 2
+	c.Dials[27] = NewDial("vfreq", "%.3f", pixel.R(columnPos[2], rowPos[0], columnPos[2]+dialWidth, rowPos[0]+dialHeight), c.SessionData.VelocityFreq, 0.01, 3.0, 0.001)
+	c.Dials[27].Page = 2
+	c.Dials[28] = NewDial("voff", "%.0f", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.VelocityOffset), 0, 1000, 1)
+	c.Dials[28].Page = 2
+	c.Dials[29] = NewDial("vmin", "%.0f", pixel.R(columnPos[1], rowPos[1], columnPos[1]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.VelocityMin), 1, 127, 1)
+	c.Dials[29].Page = 2
+	c.Dials[30] = NewDial("vmax", "%.0f", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.VelocityMax), 1, 127, 1)
+	c.Dials[30].Page = 2
+	c.Dials[31] = NewSelector("gate", pixel.R(columnPos[0], rowPos[2], columnPos[0]+dialWidth, rowPos[2]+dialHeight), GateModeNames, float64(c.SessionData.GateMode))
+	c.Dials[31].Page = 2
+	c.Dials[32] = NewDial("gfreq", "%.3f", pixel.R(columnPos[1], rowPos[2], columnPos[1]+dialWidth, rowPos[2]+dialHeight), c.SessionData.GateFreq, 0.01, 3.0, 0.001)
+	c.Dials[32].Page = 2
+	c.Dials[33] = NewDial("goff", "%.0f", pixel.R(columnPos[2], rowPos[2], columnPos[2]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.GateOffset), 0, 1000, 1)
+	c.Dials[33].Page = 2
+	c.Dials[34] = NewDial("gmin", "%.2f", pixel.R(columnPos[0], rowPos[3], columnPos[0]+dialWidth, rowPos[3]+dialHeight), c.SessionData.GateMin, 0.08, 8, 0.01)
+	c.Dials[34].Page = 2
+	c.Dials[35] = NewDial("gmax", "%.2f", pixel.R(columnPos[1], rowPos[3], columnPos[1]+dialWidth, rowPos[3]+dialHeight), c.SessionData.GateMax, 0.08, 8, 0.01)
+	c.Dials[35].Page = 2
+	// Modulation Page Dials, editing the lane selected by the first dial
+	lane := c.SessionData.ModLanes[c.SessionData.ModLane]
+	laneNames := make([]string, len(c.SessionData.ModLanes))
+	for i := range laneNames {
+		laneNames[i] = strconv.Itoa(i + 1)
+	}
+	c.Dials[36] = NewSelector("lane", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), laneNames, float64(c.SessionData.ModLane))
+	c.Dials[36].Page = 3
+	c.Dials[37] = NewSelector("mod", pixel.R(columnPos[1], rowPos[0], columnPos[1]+dialWidth, rowPos[0]+dialHeight), []string{"off", "on"}, helpers.BoolToFloat64(lane.On))
+	c.Dials[37].Page = 3
+	c.Dials[38] = NewDial("cc", "%.0f", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), float64(lane.CC), 0, 127, 1)
+	c.Dials[38].Page = 3
+	c.Dials[39] = NewDial("chan", "%.0f", pixel.R(columnPos[1], rowPos[1], columnPos[1]+dialWidth, rowPos[1]+dialHeight), float64(lane.Channel), 1, 16, 1)
+	c.Dials[39].Page = 3
+	c.Dials[40] = NewDial("rate", "%.0f", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), float64(lane.Rate), 1, metronome.TicksPerBeat, 1)
+	c.Dials[40].Page = 3
+	c.Dials[41] = NewDial("min", "%.0f", pixel.R(columnPos[0], rowPos[2], columnPos[0]+dialWidth, rowPos[2]+dialHeight), float64(lane.Min), 0, 127, 1)
+	c.Dials[41].Page = 3
+	c.Dials[42] = NewDial("max", "%.0f", pixel.R(columnPos[1], rowPos[2], columnPos[1]+dialWidth, rowPos[2]+dialHeight), float64(lane.Max), 0, 127, 1)
+	c.Dials[42].Page = 3
+	c.Dials[43] = NewDial("mfreq", "%.3f", pixel.R(columnPos[0], rowPos[3], columnPos[0]+dialWidth, rowPos[3]+dialHeight), lane.Frequency, 0.01, 3.0, 0.001)
+	c.Dials[43].Page = 3
+	c.Dials[44] = NewDial("moff", "%.0f", pixel.R(columnPos[1], rowPos[3], columnPos[1]+dialWidth, rowPos[3]+dialHeight), float64(lane.Offset), 0, 1000, 1)
+	c.Dials[44].Page = 3
+	c.Dials[45] = NewDial("mocts", "%.0f", pixel.R(columnPos[2], rowPos[3], columnPos[2]+dialWidth, rowPos[3]+dialHeight), float64(lane.Octaves), 1, 10, 1)
+	c.Dials[45].Page = 3
+	// Rhythm Page Dials, n, k and r on the main page also apply
+	c.Dials[46] = NewSelector("rhythm", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), generators.KindNames, float64(c.SessionData.Rhythm))
+	c.Dials[46].Page = 4
+	c.Dials[47] = NewSelector("algo", pixel.R(columnPos[1], rowPos[0], columnPos[1]+dialWidth, rowPos[0]+dialHeight), generators.AlgorithmNames, float64(c.SessionData.Algorithm))
+	c.Dials[47].Page = 4
+	c.Dials[48] = NewDial("dens", "%.2f", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), c.SessionData.RhythmDensity, 0, 1, 0.01)
+	c.Dials[48].Page = 4
+	c.Dials[49] = NewSelector("clave", pixel.R(columnPos[1], rowPos[1], columnPos[1]+dialWidth, rowPos[1]+dialHeight), generators.TimelineNames, float64(c.SessionData.RhythmTimeline))
+	c.Dials[49].Page = 4
+	c.Dials[50] = NewDial("thresh", "%.2f", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), c.SessionData.RhythmThreshold, -1, 1, 0.01)
+	c.Dials[50].Page = 4
+	c.Dials[51] = NewDial("accent", "%.0f", pixel.R(columnPos[0], rowPos[2], columnPos[0]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.AccentN), 0, 32, 1)
+	c.Dials[51].Page = 4
+	c.Dials[52] = NewDial("arot", "%.0f", pixel.R(columnPos[1], rowPos[2], columnPos[1]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.AccentR), 0, 32, 1)
+	c.Dials[52].Page = 4
+	c.Dials[53] = NewDial("alvl", "%.0f", pixel.R(columnPos[2], rowPos[2], columnPos[2]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.AccentLevel), 0, 127, 1)
+	c.Dials[53].Page = 4
+	c.Dials[54] = NewDial("chance", "%.0f", pixel.R(columnPos[0], rowPos[3], columnPos[0]+dialWidth, rowPos[3]+dialHeight), float64(c.SessionData.Chance), 0, 100, 1)
+	c.Dials[54].Page = 4
+	c.Dials[55] = NewDial("ratch", "%.0f", pixel.R(columnPos[1], rowPos[3], columnPos[1]+dialWidth, rowPos[3]+dialHeight), float64(c.SessionData.Ratchet), 1, float64(generators.MaxRatchet), 1)
+	c.Dials[55].Page = 4
+	c.Dials[56] = NewDial("swing", "%.0f", pixel.R(columnPos[2], rowPos[3], columnPos[2]+dialWidth, rowPos[3]+dialHeight), c.SessionData.Swing, 50, 75, 1)
+	c.Dials[56].Page = 4
+	// Arpeggiator Dials
+	c.Dials[57] = NewSelector("arp", pixel.R(columnPos[0], rowPos[4], columnPos[0]+dialWidth, rowPos[4]+dialHeight), generators.ArpModeNames, float64(c.SessionData.ArpMode))
+	c.Dials[57].Page = 4
+	c.Dials[58] = NewDial("aoct", "%.0f", pixel.R(columnPos[1], rowPos[4], columnPos[1]+dialWidth, rowPos[4]+dialHeight), float64(c.SessionData.ArpOctaves), 1, 4, 1)
+	c.Dials[58].Page = 4
+	c.Dials[59] = NewDial("arate", "%.0f", pixel.R(columnPos[2], rowPos[4], columnPos[2]+dialWidth, rowPos[4]+dialHeight), float64(c.SessionData.ArpRate), 1, 8, 1)
+	c.Dials[59].Page = 4
+	// Polymeter Page Dials, editing the layer selected by the first dial
+	layer := c.SessionData.Layers[c.SessionData.Layer]
+	layerNames := make([]string, len(c.SessionData.Layers))
+	for i := range layerNames {
+		layerNames[i] = strconv.Itoa(i + 1)
+	}
+	c.Dials[60] = NewSelector("layer", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), layerNames, float64(c.SessionData.Layer))
+	c.Dials[60].Page = 5
+	c.Dials[61] = NewSelector("lon", pixel.R(columnPos[1], rowPos[0], columnPos[1]+dialWidth, rowPos[0]+dialHeight), []string{"off", "on"}, helpers.BoolToFloat64(layer.On))
+	c.Dials[61].Page = 5
+	c.Dials[62] = NewDial("ln", "%.0f", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), float64(layer.N), 1, 32, 1)
+	c.Dials[62].Page = 5
+	c.Dials[63] = NewDial("lk", "%.0f", pixel.R(columnPos[1], rowPos[1], columnPos[1]+dialWidth, rowPos[1]+dialHeight), float64(layer.K), 1, 32, 1)
+	c.Dials[63].Page = 5
+	c.Dials[64] = NewDial("lr", "%.0f", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), float64(layer.Rotation), 0, 32, 1)
+	c.Dials[64].Page = 5
+	c.Dials[65] = NewDial("lnum", "%.0f", pixel.R(columnPos[0], rowPos[2], columnPos[0]+dialWidth, rowPos[2]+dialHeight), float64(layer.Num), 1, 16, 1)
+	c.Dials[65].Page = 5
+	c.Dials[66] = NewDial("lden", "%.0f", pixel.R(columnPos[1], rowPos[2], columnPos[1]+dialWidth, rowPos[2]+dialHeight), float64(layer.Den), 1, 16, 1)
+	c.Dials[66].Page = 5
+	// Generator Page Dials
+	c.Dials[67] = NewSelector("melody", pixel.R(columnPos[0], rowPos[0], columnPos[0]+dialWidth, rowPos[0]+dialHeight), generators.MelodyNames, float64(c.SessionData.Melody))
+	c.Dials[67].Page = 6
+	c.Dials[68] = NewDial("rule", "%.0f", pixel.R(columnPos[0], rowPos[1], columnPos[0]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.CARule), 0, 255, 1)
+	c.Dials[68].Page = 6
+	c.Dials[69] = NewDial("cseed", "%.0f", pixel.R(columnPos[1], rowPos[1], columnPos[1]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.CASeed), 0, 9999, 1)
+	c.Dials[69].Page = 6
+	c.Dials[70] = NewDial("cgen", "%.0f", pixel.R(columnPos[2], rowPos[1], columnPos[2]+dialWidth, rowPos[1]+dialHeight), float64(c.SessionData.CAGeneration), 0, float64(generators.MaxGeneration), 1)
+	c.Dials[70].Page = 6
+	c.Dials[71] = NewDial("order", "%.0f", pixel.R(columnPos[0], rowPos[2], columnPos[0]+dialWidth, rowPos[2]+dialHeight), float64(c.SessionData.Markov.Order), 1, float64(generators.MaxOrder), 1)
+	c.Dials[71].Page = 6
+	c.Dials[72] = NewDial("mrand", "%.2f", pixel.R(columnPos[1], rowPos[2], columnPos[1]+dialWidth, rowPos[2]+dialHeight), c.SessionData.MarkovRandomness, 0, 1, 0.01)
+	c.Dials[72].Page = 6
+	c.Dials[73] = NewDial("flip", "%.0f", pixel.R(columnPos[0], rowPos[3], columnPos[0]+dialWidth, rowPos[3]+dialHeight), c.SessionData.TuringFlip, 0, 100, 1)
+	c.Dials[73].Page = 6
+	c.Dials[74] = NewSelector("lock", pixel.R(columnPos[1], rowPos[3], columnPos[1]+dialWidth, rowPos[3]+dialHeight), []string{"off", "on"}, helpers.BoolToFloat64(c.SessionData.TuringLocked))
+	c.Dials[74].Page = 6
+	c.Dials[75] = NewDial("reg", "%.0f", pixel.R(columnPos[2], rowPos[3], columnPos[2]+dialWidth, rowPos[3]+dialHeight), float64(c.SessionData.TuringRegister), 0, math.MaxUint32, 1<<16)
+	c.Dials[75].Page = 6
+	c.Dials[76] = NewDial("iter", "%.0f", pixel.R(columnPos[0], rowPos[4], columnPos[0]+dialWidth, rowPos[4]+dialHeight), float64(c.SessionData.LSystemDepth), 0, float64(generators.MaxIterations), 1)
+	c.Dials[76].Page = 6
 }
 
 func (c *Controls) ResetDials() {
@@ -158,6 +329,88 @@ func (c *Controls) ResetDials() {
 	c.Dials[11].Set(float64(c.SessionData.K))
 	c.Dials[12].Set(float64(c.SessionData.R))
 	c.Dials[13].Set(float64(c.SessionData.G))
+	// Noise Seed Dial
+	c.Dials[14].Set(float64(c.SessionData.Seed))
+	// Noise Page Dials
+	c.Dials[15].Set(float64(c.SessionData.Fractal))
+	c.Dials[16].Set(c.SessionData.WarpAmount)
+	c.Dials[17].Set(c.SessionData.WarpFrequency)
+	c.Dials[18].Set(float64(c.SessionData.Source))
+	c.Dials[19].Set(c.SessionData.Drift)
+	c.Dials[20].Set(helpers.BoolToFloat64(c.SessionData.Loop))
+	c.Dials[21].Set(float64(c.SessionData.Voices))
+	c.Dials[22].Set(c.SessionData.VoiceSpread)
+	c.Dials[23].Set(float64(c.SessionData.VoiceMode))
+	c.Dials[24].Set(float64(c.SessionData.VoiceInterval))
+	// Dynamics Page Dials
+	c.Dials[25].Set(float64(c.SessionData.VelocityMode))
+	c.Dials[26].Set(float64(c.SessionData.Velocity))
+	c.Dials[27].Set(c.SessionData.VelocityFreq)
+	c.Dials[28].Set(float64(c.SessionData.VelocityOffset))
+	c.Dials[29].Set(float64(c.SessionData.VelocityMin))
+	c.Dials[30].Set(float64(c.SessionData.VelocityMax))
+	c.Dials[31].Set(float64(c.SessionData.GateMode))
+	c.Dials[32].Set(c.SessionData.GateFreq)
+	c.Dials[33].Set(float64(c.SessionData.GateOffset))
+	c.Dials[34].Set(c.SessionData.GateMin)
+	c.Dials[35].Set(c.SessionData.GateMax)
+	// Modulation Page Dials
+	c.Dials[36].Set(float64(c.SessionData.ModLane))
+	c.ResetModDials()
+	// Rhythm Page Dials
+	c.Dials[46].Set(float64(c.SessionData.Rhythm))
+	c.Dials[47].Set(float64(c.SessionData.Algorithm))
+	c.Dials[48].Set(c.SessionData.RhythmDensity)
+	c.Dials[49].Set(float64(c.SessionData.RhythmTimeline))
+	c.Dials[50].Set(c.SessionData.RhythmThreshold)
+	c.Dials[51].Set(float64(c.SessionData.AccentN))
+	c.Dials[52].Set(float64(c.SessionData.AccentR))
+	c.Dials[53].Set(float64(c.SessionData.AccentLevel))
+	c.Dials[54].Set(float64(c.SessionData.Chance))
+	c.Dials[55].Set(float64(c.SessionData.Ratchet))
+	c.Dials[56].Set(c.SessionData.Swing)
+	c.Dials[57].Set(float64(c.SessionData.ArpMode))
+	c.Dials[58].Set(float64(c.SessionData.ArpOctaves))
+	c.Dials[59].Set(float64(c.SessionData.ArpRate))
+	// Polymeter Page Dials
+	c.Dials[60].Set(float64(c.SessionData.Layer))
+	c.ResetLayerDials()
+	// Generator Page Dials
+	c.Dials[67].Set(float64(c.SessionData.Melody))
+	c.Dials[68].Set(float64(c.SessionData.CARule))
+	c.Dials[69].Set(float64(c.SessionData.CASeed))
+	c.Dials[70].Set(float64(c.SessionData.CAGeneration))
+	c.Dials[71].Set(float64(c.SessionData.Markov.Order))
+	c.Dials[72].Set(c.SessionData.MarkovRandomness)
+	c.Dials[73].Set(c.SessionData.TuringFlip)
+	c.Dials[74].Set(helpers.BoolToFloat64(c.SessionData.TuringLocked))
+	c.Dials[75].Set(float64(c.SessionData.TuringRegister))
+	c.Dials[76].Set(float64(c.SessionData.LSystemDepth))
+}
+
+// ResetLayerDials sets the polymeter dials from the layer selected on the layer dial
+func (c *Controls) ResetLayerDials() {
+	layer := c.SessionData.Layers[int(c.Dials[60].Value)]
+	c.Dials[61].Set(helpers.BoolToFloat64(layer.On))
+	c.Dials[62].Set(float64(layer.N))
+	c.Dials[63].Set(float64(layer.K))
+	c.Dials[64].Set(float64(layer.Rotation))
+	c.Dials[65].Set(float64(layer.Num))
+	c.Dials[66].Set(float64(layer.Den))
+}
+
+// ResetModDials sets the modulation dials from the lane selected on the lane dial
+func (c *Controls) ResetModDials() {
+	lane := c.SessionData.ModLanes[int(c.Dials[36].Value)]
+	c.Dials[37].Set(helpers.BoolToFloat64(lane.On))
+	c.Dials[38].Set(float64(lane.CC))
+	c.Dials[39].Set(float64(lane.Channel))
+	c.Dials[40].Set(float64(lane.Rate))
+	c.Dials[41].Set(float64(lane.Min))
+	c.Dials[42].Set(float64(lane.Max))
+	c.Dials[43].Set(lane.Frequency)
+	c.Dials[44].Set(float64(lane.Offset))
+	c.Dials[45].Set(float64(lane.Octaves))
 }
 
 func (c *Controls) Compose() {
@@ -191,10 +444,24 @@ func (c *Controls) Compose() {
 		c.Typ.DrawTextToBatch(str, pixel.V(strX, strY), color.RGBA{0x00, 0x00, 0x00, 0xff}, c.Typ.TxtBatch, c.Typ.Txt)
 	}
 
+	for i := range c.PageButtons {
+
+		// Labels
+		str := c.PageButtons[i].Label
+		strX := c.PageButtons[i].Rect.Min.X + (c.PageButtons[i].Rect.W() / 2) - (c.Typ.Txt.BoundsOf(str).W() / 2)
+		strY := c.PageButtons[i].Rect.Min.Y + (c.PageButtons[i].Rect.H() / 2) - (c.Typ.Txt.BoundsOf(str).H() / 3)
+		c.Typ.DrawTextToBatch(str, pixel.V(strX, strY), color.RGBA{0x00, 0x00, 0x00, 0xff}, c.Typ.TxtBatch, c.Typ.Txt)
+	}
+
 	for i := range c.Dials {
 
+		// Only the current page is shown
+		if c.Dials[i].Page != c.Page {
+			continue
+		}
+
 		// Values
-		str := fmt.Sprintf(c.Dials[i].ValueFrmt, c.Dials[i].Value)
+		str := c.Dials[i].ValueString()
 		strX := c.Dials[i].center.X - (c.Typ.Txt.BoundsOf(str).W() / 2)
 		strY := c.Dials[i].center.Y - (c.Typ.Txt.BoundsOf(str).H() / 3) + 5
 		c.Typ.DrawTextToBatch(str, pixel.V(strX, strY), color.RGBA{0x00, 0x00, 0x00, 0xff}, c.Typ.TxtBatch, c.Typ.Txt)
@@ -220,8 +487,13 @@ func (c *Controls) DrawTo(imd *imdraw.IMDraw) {
 	for i := range c.ModeButtons {
 		c.ModeButtons[i].Imd.Draw(c.ImdBatch)
 	}
+	for i := range c.PageButtons {
+		c.PageButtons[i].Imd.Draw(c.ImdBatch)
+	}
 	for i := range c.Dials {
-		c.Dials[i].DrawTo(c.ImdBatch)
+		if c.Dials[i].Page == c.Page {
+			c.Dials[i].DrawTo(c.ImdBatch)
+		}
 	}
 
 	// Draw batch
@@ -253,6 +525,10 @@ func (c *Controls) RespondToInput(win *pixelgl.Window) {
 					Value: 1.0,
 				}
 				c.SendToOutputChannels(signal)
+				// Training switches the melody source to the model
+				if c.Buttons[i].Label == "train" {
+					c.Dials[67].Set(float64(generators.MelodyMarkov))
+				}
 				c.Compose()
 			}
 		}
@@ -280,8 +556,16 @@ func (c *Controls) RespondToInput(win *pixelgl.Window) {
 			c.Compose()
 		}
 
+		for i := range c.PageButtons {
+			if c.PageButtons[i].PosInBounds(pos) {
+				c.SetPage(i)
+			}
+		}
+
 		for i := range c.Dials {
-			c.Dials[i].JustPressed(pos)
+			if c.Dials[i].Page == c.Page {
+				c.Dials[i].JustPressed(pos)
+			}
 		}
 	}
 
@@ -333,13 +617,12 @@ func (c *Controls) RespondToInput(win *pixelgl.Window) {
 		}
 
 		for i := range c.Dials {
-			c.Dials[i].Pressed(pos)
+			// Only dials on the shown page were pressed, see JustPressed
+			if c.Dials[i].Page == c.Page {
+				c.Dials[i].Pressed(pos)
+			}
 			if c.Dials[i].IsUnread {
-				signal := signals.Signal{
-					Label: c.Dials[i].Label,
-					Value: c.Dials[i].Value,
-				}
-				c.SendToOutputChannels(signal)
+				c.SendDial(i)
 				c.Dials[i].IsUnread = false
 				c.Compose()
 			}
@@ -353,17 +636,13 @@ func (c *Controls) RespondToInput(win *pixelgl.Window) {
 		// if keyboard input is not empty and mouse is on a dial, set dial value to keyboard input
 		if c.SessionData.KeyboardNumInput != "" {
 			for i := range c.Dials {
-				if helpers.PosInBounds(pos, c.Dials[i].Rect) {
+				if c.Dials[i].Page == c.Page && helpers.PosInBounds(pos, c.Dials[i].Rect) {
 					val, err := strconv.ParseFloat(c.SessionData.KeyboardNumInput, 64)
 					if err != nil {
 						log.Println("Error parsing keyboard input to float64")
 					}
 					c.Dials[i].Set(val)
-					signal := signals.Signal{
-						Label: c.Dials[i].Label,
-						Value: c.Dials[i].Value,
-					}
-					c.SendToOutputChannels(signal)
+					c.SendDial(i)
 					c.Compose()
 				}
 			}
@@ -382,6 +661,33 @@ func (c *Controls) RespondToInput(win *pixelgl.Window) {
 	}
 }
 
+// SendDial sends the value of dial i to all subscribers
+func (c *Controls) SendDial(i int) {
+
+	signal := signals.Signal{
+		Label: c.Dials[i].Label,
+		Value: c.Dials[i].Value,
+	}
+	c.SendToOutputChannels(signal)
+
+	// The modulation and polymeter dials follow the selected lane and layer
+	switch c.Dials[i].Label {
+	case "lane":
+		c.ResetModDials()
+	case "layer":
+		c.ResetLayerDials()
+	}
+}
+
+// SetPage shows the dials on the given page
+func (c *Controls) SetPage(page int) {
+	for i := range c.PageButtons {
+		c.PageButtons[i].SetEngaged(i == page)
+	}
+	c.Page = page
+	c.Compose()
+}
+
 func (c *Controls) ListenToInputSessionChannel() {
 	go func() {
 		for {
@@ -395,6 +701,23 @@ func (c *Controls) ListenToInputSessionChannel() {
 			case "loaded":
 				fmt.Println("controls: update from session data")
 				c.ResetDials()
+			case "notated", "trained", "rewritten":
+				c.ResetDials()
+			default:
+			}
+		}
+	}()
+}
+
+func (c *Controls) ListenToInputGridChannel() {
+	go func() {
+		for {
+			signal := <-c.InputGridChannel
+			switch signal.Label {
+			case "evolved":
+				// The generators that evolve each loop move their dials on
+				c.Dials[70].Set(float64(c.SessionData.CAGeneration))
+				c.Dials[75].Set(float64(c.SessionData.TuringRegister))
 			default:
 			}
 		}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"image/color"
 	"math"
 
@@ -17,6 +18,8 @@ type Dial struct {
 	Label                string
 	Value                float64
 	ValueFrmt            string
+	Options              []string
+	Page                 int
 	min                  float64
 	max                  float64
 	scale                float64
@@ -51,6 +54,15 @@ func NewDial(label string, valueFrmt string, r pixel.Rect, value, min, max, scal
 	return d
 }
 
+// NewSelector returns a dial that steps through a list of named options
+func NewSelector(label string, r pixel.Rect, options []string, value float64) *Dial {
+
+	d := NewDial(label, "%.0f", r, value, 0, float64(len(options)-1), 0.05)
+	d.Options = options
+
+	return d
+}
+
 func (d *Dial) Compose() {
 
 	d.ImdStatic.Clear()
@@ -118,17 +130,35 @@ func (d *Dial) Pressed(pos pixel.Vec) {
 		d.initialMousePosition = pos
 
 		if d.newValue != d.Value {
-			d.Value = helpers.ConstrainFloat64(d.newValue, d.min, d.max)
-			d.newValue = d.Value
-			d.IsUnread = true
-			d.Update()
+			value := helpers.ConstrainFloat64(d.newValue, d.min, d.max)
+			d.newValue = value
+			// Selectors only land on whole options
+			if d.Options != nil {
+				value = math.Round(value)
+			}
+			if value != d.Value {
+				d.Value = value
+				d.IsUnread = true
+				d.Update()
+			}
 		}
 	}
 }
 
 func (d *Dial) Set(v float64) {
 	d.Value = helpers.ConstrainFloat64(v, d.min, d.max)
+	if d.Options != nil {
+		d.Value = math.Round(d.Value)
+	}
 	d.newValue = d.Value
 	d.IsUnread = true
 	d.Update()
 }
+
+// ValueString formats the dial value, or the selected option for selectors
+func (d *Dial) ValueString() string {
+	if d.Options != nil {
+		return d.Options[int(d.Value)]
+	}
+	return fmt.Sprintf(d.ValueFrmt, d.Value)
+}
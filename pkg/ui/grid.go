@@ -3,8 +3,10 @@ package ui
 import (
 	"fmt"
 	"image/color"
+	"log"
 	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 
 	"github.com/faiface/pixel"
@@ -12,6 +14,9 @@ import (
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/willgarrison/go-noise/pkg/generators"
 	"github.com/willgarrison/go-noise/pkg/helpers"
+	"github.com/willgarrison/go-noise/pkg/metronome"
+	"github.com/willgarrison/go-noise/pkg/modulation"
+	"github.com/willgarrison/go-noise/pkg/noise"
 	"github.com/willgarrison/go-noise/pkg/session"
 	"github.com/willgarrison/go-noise/pkg/signals"
 	"github.com/willgarrison/go-noise/pkg/simplexnoise"
@@ -19,10 +24,48 @@ import (
 	"gitlab.com/gomidi/midi/writer"
 )
 
+// Velocity modes choose where note velocity comes from
+const (
+	VelocityFixed uint8 = iota
+	VelocityNoise
+)
+
+// VelocityModeNames are short labels for each velocity mode, indexed by value
+var VelocityModeNames = []string{"fixed", "noise"}
+
+// noteChannel is the writer channel (0-15) notes are sent on
+const noteChannel uint8 = 1
+
+// Gate modes choose where note length comes from
+const (
+	GateFixed uint8 = iota
+	GateNoise
+)
+
+// GateModeNames are short labels for each gate mode, indexed by value
+var GateModeNames = []string{"fixed", "noise"}
+
+// velocityLaneY and gateLaneY keep the lanes apart from the melody lines
+const (
+	velocityLaneY float32 = 1000.5
+	gateLaneY     float32 = 2000.5
+)
+
+// Voice modes constrain the rows of additional noise voices
+const (
+	VoiceFree uint8 = iota
+	VoiceChord
+	VoiceInterval
+)
+
+// VoiceModeNames are short labels for each voice mode, indexed by value
+var VoiceModeNames = []string{"free", "chord", "interval"}
+
 type Note struct {
 	index       uint8
-	release     uint8
-	beatsPlayed uint8
+	velocity    uint8
+	gate        uint16 // in ticks
+	ticksPlayed uint16
 	isPlaying   bool
 }
 
@@ -34,14 +77,31 @@ type Grid struct {
 	InputBeatChannel    chan signals.Signal
 	InputCtrlChannel    chan signals.Signal
 	InputSessionChannel chan signals.Signal
+	OutputChannels      []chan signals.Signal
 	BeatIndex           uint8
+	Ticks               uint64
+	Rand                *rand.Rand
+	Ratchet             uint8
+	RatchetNotes        []uint8
+	ArpNotes            []uint8
+	StrikeTick          uint64
+	PendingStep         uint8
+	PendingTick         int
+	StruckEarly         bool
+	LayerPatterns       []*generators.Pattern
+	ModValues           []int
 	Notes               []Note
 	NotesToStrike       []uint8
+	Velocities          []uint8
+	Gates               []uint16
 	Scale               []uint8
 	NoteNames           []string
 	MidiWriter          *writer.Writer
 	MidiOutput          midi.Out
 	Playhead            *Playhead
+	Noise               noise.NoiseSource
+	Source              noise.Source // The source Noise was built as
+	Warp                *simplexnoise.Generator
 	Typ                 *Typography
 	IsPlaying           bool
 	SignalReceived      bool
@@ -64,7 +124,7 @@ func NewGrid(r pixel.Rect, ao midi.Out, sessionData *session.SessionData) *Grid
 	g.Notes = make([]Note, 128)
 	for i := range g.Notes {
 		g.Notes[i].index = uint8(i)
-		g.Notes[i].release = uint8(g.SessionData.Release)
+		g.Notes[i].gate = metronome.TicksPerBeat
 	}
 
 	// Initialize playhead
@@ -74,7 +134,7 @@ func NewGrid(r pixel.Rect, ao midi.Out, sessionData *session.SessionData) *Grid
 	g.SetScale(0)
 
 	g.MidiWriter = writer.New(ao)
-	g.MidiWriter.SetChannel(1)
+	g.MidiWriter.SetChannel(noteChannel)
 
 	g.InputCtrlChannel = make(chan signals.Signal)
 	g.ListenToInputCtrlChannel()
@@ -98,17 +158,25 @@ func (g *Grid) Compose() {
 		g.Matrix[i] = make([]uint32, int(g.SessionData.YSteps))
 	}
 
-	xPos := uint32(0)
-	for xPos < g.SessionData.XSteps {
-		val := simplexnoise.Fbm(float32(xPos+g.SessionData.Offset), 0, float32(g.SessionData.Frequency), float32(g.SessionData.Lacunarity), float32(g.SessionData.Gain), int(g.SessionData.Octaves))
-		yPos := uint32(math.Round(helpers.ReRange(float64(val), -1, 1, 0, float64(g.SessionData.YSteps-1))))
-		g.Matrix[xPos][yPos] = 1
-		xPos++
+	// Rebuild the noise sources when the source or seed changes
+	if g.Noise == nil || g.Source != g.SessionData.Source || g.Warp.Seed != g.SessionData.Seed {
+		g.Noise = noise.New(g.SessionData.Source, g.SessionData.Seed)
+		g.Source = g.SessionData.Source
+		g.Warp = simplexnoise.New(g.SessionData.Seed)
 	}
 
-	// Set beatlength
-	for i := range g.Notes {
-		g.Notes[i].release = g.SessionData.Release
+	g.ComposeMelody()
+
+	// Velocity lane
+	g.Velocities = make([]uint8, int(g.SessionData.XSteps))
+	for x := range g.Velocities {
+		g.Velocities[x] = g.LaneVelocity(uint32(x))
+	}
+
+	// Gate lane
+	g.Gates = make([]uint16, int(g.SessionData.XSteps))
+	for x := range g.Gates {
+		g.Gates[x] = g.LaneGate(uint32(x))
 	}
 
 	// Set active blocks
@@ -120,7 +188,25 @@ func (g *Grid) Compose() {
 		}
 	}
 
-	g.SessionData.UserPattern, _ = generators.NewEuclid(g.SessionData.N, g.SessionData.K, g.SessionData.R, g.SessionData.G)
+	pattern, err := g.SessionData.RhythmGenerator().Generate()
+	if err != nil {
+		log.Println("RhythmGenerator.Generate:", err)
+	} else {
+		g.SessionData.UserPattern = pattern
+	}
+
+	// Polymeter layers, nil where a layer is off or invalid
+	layerPatterns := make([]*generators.Pattern, len(g.SessionData.Layers))
+	for i, layer := range g.SessionData.Layers {
+		if !layer.On {
+			continue
+		}
+		layerPatterns[i], err = layer.Generate()
+		if err != nil {
+			log.Println("Layer.Generate:", err)
+		}
+	}
+	g.LayerPatterns = layerPatterns
 
 	// Clear
 	g.Imd.Clear()
@@ -128,6 +214,8 @@ func (g *Grid) Compose() {
 
 	backgroundColumnColor := color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
 	activeColumnColor := color.RGBA{0xee, 0xee, 0xee, 0xff}
+	accentColumnColor := color.RGBA{0xe4, 0xe4, 0xe4, 0xff}
+	ghostColumnColor := color.RGBA{0xf6, 0xf6, 0xf6, 0xff}
 
 	// Background
 	g.Imd.Color = backgroundColumnColor
@@ -145,9 +233,14 @@ func (g *Grid) Compose() {
 	// Draw active columns and blocks
 	for x := range g.Matrix {
 
-		// Draw active columns
+		// Draw active columns, darker when accented and lighter for ghost hits
 		if g.SessionData.UserPattern.Rhythm[x%rhythmLength] == 1 {
 			g.Imd.Color = activeColumnColor
+			if accent := g.SessionData.UserPattern.Accent(x % rhythmLength); accent > generators.AccentNormal {
+				g.Imd.Color = accentColumnColor
+			} else if accent < generators.AccentNormal {
+				g.Imd.Color = ghostColumnColor
+			}
 			g.Imd.Push(
 				pixel.V(
 					g.Rect.Min.X+(float64(x)*blockWidth),
@@ -161,6 +254,17 @@ func (g *Grid) Compose() {
 			g.Imd.Rectangle(0)
 		}
 
+		// Draw the timing offset of the column as a mark along its base
+		if x < len(g.SessionData.Microtiming) && g.SessionData.Microtiming[x] != 0 {
+			markX := g.Rect.Min.X + (float64(x) * blockWidth) + (blockWidth * (0.5 + g.SessionData.Microtiming[x]))
+			g.Imd.Color = color.RGBA{0x1a, 0x6a, 0x80, 0xff}
+			g.Imd.Push(
+				pixel.V(markX-1, g.Rect.Min.Y),
+				pixel.V(markX+1, g.Rect.Min.Y+(blockHeight/4)),
+			)
+			g.Imd.Rectangle(0)
+		}
+
 		// Draw active blocks
 		for y := range g.Matrix[x] {
 			if g.Matrix[x][y] > 0 {
@@ -187,6 +291,71 @@ func (g *Grid) Compose() {
 					),
 				)
 				g.Imd.Rectangle(0)
+
+				// Velocity override
+				if g.Matrix[x][y] == 2 && g.SessionData.UserVelocity[x][y] != 0 {
+					g.Imd.Color = color.RGBA{0x1a, 0x6a, 0x80, 0xff}
+					g.Imd.Push(
+						pixel.V(
+							g.Rect.Min.X+(float64(x)*blockWidth),
+							g.Rect.Min.Y+(float64(y)*blockHeight),
+						),
+						pixel.V(
+							g.Rect.Min.X+(float64(x)*blockWidth)+(blockWidth*float64(g.SessionData.UserVelocity[x][y])/127),
+							g.Rect.Min.Y+(float64(y)*blockHeight)+(blockHeight/4),
+						),
+					)
+					g.Imd.Rectangle(0)
+				}
+
+				// Gate override
+				if g.Matrix[x][y] == 2 && g.SessionData.UserGate[x][y] != 0 {
+					g.Imd.Color = color.RGBA{0x1a, 0x6a, 0x80, 0xff}
+					g.Imd.Push(
+						pixel.V(
+							g.Rect.Min.X+(float64(x)*blockWidth),
+							g.Rect.Min.Y+(float64(y)*blockHeight)+(blockHeight*3/4),
+						),
+						pixel.V(
+							g.Rect.Min.X+(float64(x)*blockWidth)+(blockWidth*math.Min(g.SessionData.UserGate[x][y]/8, 1)),
+							g.Rect.Min.Y+(float64(y)*blockHeight)+blockHeight,
+						),
+					)
+					g.Imd.Rectangle(0)
+				}
+			}
+		}
+	}
+
+	// Draw each polymeter layer as a strip along the top of the grid,
+	// its hits at their place in time and a bar where each cycle starts
+	layerColors := []color.RGBA{
+		{0xe0, 0x8a, 0x2c, 0xff},
+		{0x4c, 0xa8, 0x5a, 0xff},
+		{0x8e, 0x5c, 0xc4, 0xff},
+	}
+	for i, layerPattern := range g.LayerPatterns {
+		if layerPattern == nil {
+			continue
+		}
+		stepWidth := g.SessionData.Layers[i].Beats() * blockWidth
+		top := g.Rect.Max.Y - float64(i)*6
+		g.Imd.Color = layerColors[i%len(layerColors)]
+		for j := 0; float64(j)*stepWidth < g.W; j++ {
+			left := g.Rect.Min.X + float64(j)*stepWidth
+			if layerPattern.Rhythm[j%layerPattern.Len()] == 1 {
+				g.Imd.Push(
+					pixel.V(left, top-5),
+					pixel.V(math.Min(left+math.Max(stepWidth-1, 2), g.Rect.Max.X), top-1),
+				)
+				g.Imd.Rectangle(0)
+			}
+			if j%layerPattern.Len() == 0 {
+				g.Imd.Push(
+					pixel.V(left, top-6),
+					pixel.V(left, top),
+				)
+				g.Imd.Line(2)
 			}
 		}
 	}
@@ -241,6 +410,219 @@ func (g *Grid) Compose() {
 	}
 }
 
+// ComposeMelody fills the matrix from the selected melody source
+func (g *Grid) ComposeMelody() {
+	switch g.SessionData.Melody {
+	case generators.MelodyAutomaton:
+		// Each column is a generation, each row a cell
+		a := generators.Automaton{Rule: g.SessionData.CARule, Seed: g.SessionData.CASeed}
+		rows := a.Rows(int(g.SessionData.YSteps), g.SessionData.CAGeneration, int(g.SessionData.XSteps))
+		for x := range g.Matrix {
+			for y := range g.Matrix[x] {
+				g.Matrix[x][y] = uint32(rows[x][y])
+			}
+		}
+	case generators.MelodyMarkov:
+		g.ComposeMarkov()
+	case generators.MelodyTuring:
+		// The register's pitches span the rows
+		pitches := g.SessionData.Turing().Pitches(int(g.SessionData.XSteps))
+		for x, pitch := range pitches {
+			g.Matrix[x][uint32(pitch)*g.SessionData.YSteps/generators.TuringPitches] = 1
+		}
+	case generators.MelodyLSystem:
+		g.ComposeLSystem()
+	default:
+		g.ComposeNoise()
+	}
+}
+
+// ComposeMarkov fills the matrix with a line generated by the session's
+// Markov model, or from the noise until the model is trained
+func (g *Grid) ComposeMarkov() {
+
+	m := &g.SessionData.Markov
+	if !m.Trained() {
+		g.ComposeNoise()
+		return
+	}
+
+	// Lines start from the middle row and fold back by octaves to stay on the grid
+	rows := int(g.SessionData.YSteps)
+	root := int(g.Scale[rows/2])
+	low, high := int(g.Scale[0]), int(g.Scale[rows-1])
+
+	for _, n := range m.Generate(int(g.SessionData.XSteps), g.SessionData.MarkovRandomness, g.SessionData.Seed) {
+		key := root + n.Key
+		for key > high && key-12 >= low {
+			key -= 12
+		}
+		for key < low && key+12 <= high {
+			key += 12
+		}
+		g.Matrix[n.Step][g.NearestRow(key)] = 1
+	}
+}
+
+// ComposeLSystem fills the matrix with the session's L-system phrase,
+// starting from the middle row and wrapping at the edges of the grid
+func (g *Grid) ComposeLSystem() {
+
+	l, err := generators.ParseLSystem(g.SessionData.LSystem)
+	if err != nil {
+		log.Println("generators.ParseLSystem:", err)
+		g.ComposeNoise()
+		return
+	}
+	l.Iterations = g.SessionData.LSystemDepth
+
+	rows := int(g.SessionData.YSteps)
+	for _, n := range l.Phrase(int(g.SessionData.XSteps)) {
+		y := ((rows/2+n.Key)%rows + rows) % rows
+		g.Matrix[n.Step][y] = 1
+	}
+}
+
+// NearestRow returns the row whose note is closest to key, in semitones above low
+func (g *Grid) NearestRow(key int) uint32 {
+	distance := func(y uint32) float64 {
+		return math.Abs(float64(int(g.Scale[y]) - key))
+	}
+	nearest := uint32(0)
+	for y := uint32(1); y < g.SessionData.YSteps; y++ {
+		if distance(y) < distance(nearest) {
+			nearest = y
+		}
+	}
+	return nearest
+}
+
+// TrainMarkov trains the session's Markov model on the user's cells, the
+// lowest in each column, as a loop the length of the grid
+func (g *Grid) TrainMarkov() {
+
+	notes := []generators.Note{}
+	for x := uint32(0); x < g.SessionData.XSteps; x++ {
+		for y := uint32(0); y < g.SessionData.YSteps; y++ {
+			if g.SessionData.UserMatrix[x][y] == 2 {
+				notes = append(notes, generators.Note{Key: int(g.Scale[y]), Step: int(x)})
+				break
+			}
+		}
+	}
+
+	g.SessionData.Markov.Train(generators.NewLine(notes, int(g.SessionData.XSteps)))
+	g.SessionData.Melody = generators.MelodyMarkov
+}
+
+// ComposeNoise draws a line through the noise field for each voice
+func (g *Grid) ComposeNoise() {
+
+	// Evolve mode moves the line through the noise field along y
+	y := float32(g.SessionData.EvolveY)
+
+	voices := g.SessionData.Voices
+	if voices < 1 {
+		voices = 1
+	}
+
+	xPos := uint32(0)
+	for xPos < g.SessionData.XSteps {
+		// Each voice samples its own line, further along y
+		rows := make([]uint32, 0, voices)
+		for v := uint8(0); v < voices; v++ {
+			yPos := g.NoiseRow(xPos, y+float32(v)*float32(g.SessionData.VoiceSpread))
+			if v > 0 {
+				yPos = g.ConstrainVoice(yPos, rows)
+			}
+			rows = append(rows, yPos)
+			g.Matrix[xPos][yPos] = 1
+		}
+		xPos++
+	}
+}
+
+// NoiseRow samples the noise field for column xPos along line y
+// and returns the row it lands on
+func (g *Grid) NoiseRow(xPos uint32, y float32) uint32 {
+
+	params := g.NoiseParams()
+	low, high := g.SessionData.Fractal.Range()
+
+	var val float32
+	if g.SessionData.Loop {
+		// Loop mode samples around a circle one step apart per column,
+		// so the last step flows into the first
+		radius := float32(g.SessionData.XSteps) / (2 * math.Pi)
+		t := g.Warp.WarpLoop(float32(xPos)/float32(g.SessionData.XSteps), radius, y, float32(g.SessionData.WarpFrequency), float32(g.SessionData.WarpAmount))
+		val = noise.Loop(g.Noise, t, radius, float32(g.SessionData.Offset), y, params)
+	} else {
+		x := g.Warp.WarpX(float32(xPos+g.SessionData.Offset), y, float32(g.SessionData.WarpFrequency), float32(g.SessionData.WarpAmount))
+		val = g.Noise.Sample(x, y, params)
+	}
+
+	return uint32(math.Round(helpers.ReRange(float64(val), float64(low), float64(high), 0, float64(g.SessionData.YSteps-1))))
+}
+
+// ConstrainVoice moves the row of an additional voice according to the
+// session's voice mode, given the rows of the voices before it
+func (g *Grid) ConstrainVoice(row uint32, rows []uint32) uint32 {
+
+	allowed := func(r uint32) bool {
+		for _, other := range rows {
+			switch g.SessionData.VoiceMode {
+			case VoiceChord:
+				if r == other {
+					return false
+				}
+			case VoiceInterval:
+				distance := int(r) - int(other)
+				if distance < 0 {
+					distance = -distance
+				}
+				if distance < int(g.SessionData.VoiceInterval) {
+					return false
+				}
+			}
+		}
+		if g.SessionData.VoiceMode == VoiceChord {
+			// Triad tones above or below the first voice
+			interval := (int(g.Scale[r]) - int(g.Scale[rows[0]])) % 12
+			if interval < 0 {
+				interval += 12
+			}
+			return interval == 3 || interval == 4 || interval == 7 || interval == 0
+		}
+		return true
+	}
+
+	if g.SessionData.VoiceMode == VoiceFree {
+		return row
+	}
+
+	// Search outwards from the sampled row for the nearest allowed row
+	for distance := 0; distance < int(g.SessionData.YSteps); distance++ {
+		for _, r := range []int{int(row) + distance, int(row) - distance} {
+			if r >= 0 && r < int(g.SessionData.YSteps) && allowed(uint32(r)) {
+				return uint32(r)
+			}
+		}
+	}
+
+	return row
+}
+
+// NoiseParams returns the session's noise settings
+func (g *Grid) NoiseParams() noise.Params {
+	return noise.Params{
+		Frequency:  float32(g.SessionData.Frequency),
+		Lacunarity: float32(g.SessionData.Lacunarity),
+		Gain:       float32(g.SessionData.Gain),
+		Octaves:    int(g.SessionData.Octaves),
+		Fractal:    g.SessionData.Fractal,
+	}
+}
+
 func (g *Grid) DrawTo(imd *imdraw.IMDraw) {
 	g.Imd.Draw(imd)
 	g.Playhead.DrawTo(imd)
@@ -251,12 +633,15 @@ func (g *Grid) RespondToInput(win *pixelgl.Window) {
 	if win.JustPressed(pixelgl.MouseButtonLeft) {
 		pos := win.MousePosition()
 		if helpers.PosInBounds(pos, g.Rect) {
-			x := uint32((pos.X - g.Rect.Min.X) / (g.W / float64(g.SessionData.XSteps)))
-			y := uint32((pos.Y - g.Rect.Min.Y) / (g.H / float64(g.SessionData.YSteps)))
+			x, y := g.CellAt(pos)
 			if g.SessionData.UserMatrix[x][y] == 2 {
 				g.SessionData.UserMatrix[x][y] = 0
+				g.SessionData.UserVelocity[x][y] = 0
+				g.SessionData.UserGate[x][y] = 0
+				g.SessionData.UserOrder[x][y] = 0
 			} else {
 				g.SessionData.UserMatrix[x][y] = 2
+				g.SessionData.UserOrder[x][y] = g.NextOrder(x)
 			}
 			g.Compose()
 		}
@@ -265,10 +650,12 @@ func (g *Grid) RespondToInput(win *pixelgl.Window) {
 	if win.JustPressed(pixelgl.MouseButtonRight) {
 		pos := win.MousePosition()
 		if helpers.PosInBounds(pos, g.Rect) {
-			x := uint32((pos.X - g.Rect.Min.X) / (g.W / float64(g.SessionData.XSteps)))
-			y := uint32((pos.Y - g.Rect.Min.Y) / (g.H / float64(g.SessionData.YSteps)))
+			x, y := g.CellAt(pos)
 			if g.SessionData.UserMatrix[x][y] == 3 {
 				g.SessionData.UserMatrix[x][y] = 0
+				g.SessionData.UserVelocity[x][y] = 0
+				g.SessionData.UserGate[x][y] = 0
+				g.SessionData.UserOrder[x][y] = 0
 			} else {
 				g.SessionData.UserMatrix[x][y] = 3
 			}
@@ -276,12 +663,124 @@ func (g *Grid) RespondToInput(win *pixelgl.Window) {
 		}
 	}
 
+	// Scroll over a user cell to override its velocity,
+	// or its gate while holding shift.
+	// Scroll anywhere in a column while holding control to shift its timing.
+	if scroll := win.MouseScroll(); scroll.Y != 0 {
+		pos := win.MousePosition()
+		if helpers.PosInBounds(pos, g.Rect) {
+			x, y := g.CellAt(pos)
+			if win.Pressed(pixelgl.KeyLeftControl) || win.Pressed(pixelgl.KeyRightControl) {
+				if int(x) < len(g.SessionData.Microtiming) {
+					offset := g.SessionData.Microtiming[x] + scroll.Y/metronome.TicksPerBeat
+					g.SessionData.Microtiming[x] = helpers.ConstrainFloat64(offset, -0.5, 0.5)
+					g.Compose()
+				}
+			} else if g.SessionData.UserMatrix[x][y] == 2 {
+				if win.Pressed(pixelgl.KeyLeftShift) || win.Pressed(pixelgl.KeyRightShift) {
+					gate := float64(g.Gate(x, y))/metronome.TicksPerBeat + scroll.Y/metronome.TicksPerBeat
+					g.SessionData.UserGate[x][y] = helpers.ConstrainFloat64(gate, 1.0/metronome.TicksPerBeat, 8)
+				} else {
+					velocity := float64(g.Velocity(x, y)) + scroll.Y*4
+					g.SessionData.UserVelocity[x][y] = uint8(helpers.ConstrainFloat64(velocity, 1, 127))
+				}
+				g.Compose()
+			}
+		}
+	}
+
 	if g.SignalReceived {
 		g.SignalReceived = false
 		g.Compose()
 	}
 }
 
+// CellAt returns the column and row under pos
+func (g *Grid) CellAt(pos pixel.Vec) (uint32, uint32) {
+	x := uint32((pos.X - g.Rect.Min.X) / (g.W / float64(g.SessionData.XSteps)))
+	y := uint32((pos.Y - g.Rect.Min.Y) / (g.H / float64(g.SessionData.YSteps)))
+	return x, y
+}
+
+// LaneVelocity returns the velocity for column x from the session's velocity mode
+func (g *Grid) LaneVelocity(x uint32) uint8 {
+
+	if g.SessionData.VelocityMode != VelocityNoise {
+		return g.SessionData.Velocity
+	}
+
+	params := noise.Params{
+		Frequency:  float32(g.SessionData.VelocityFreq),
+		Lacunarity: 2,
+		Gain:       0.5,
+		Octaves:    1,
+		Fractal:    simplexnoise.FractalNormalized,
+	}
+
+	val := g.Noise.Sample(float32(x+g.SessionData.VelocityOffset), velocityLaneY, params)
+
+	return uint8(math.Round(helpers.ReRange(float64(val), -1, 1, float64(g.SessionData.VelocityMin), float64(g.SessionData.VelocityMax))))
+}
+
+// LaneGate returns the gate for column x in ticks, from the session's gate mode
+func (g *Grid) LaneGate(x uint32) uint16 {
+
+	if g.SessionData.GateMode != GateNoise {
+		return beatsToTicks(float64(g.SessionData.Release))
+	}
+
+	params := noise.Params{
+		Frequency:  float32(g.SessionData.GateFreq),
+		Lacunarity: 2,
+		Gain:       0.5,
+		Octaves:    1,
+		Fractal:    simplexnoise.FractalNormalized,
+	}
+
+	val := g.Noise.Sample(float32(x+g.SessionData.GateOffset), gateLaneY, params)
+
+	return beatsToTicks(helpers.ReRange(float64(val), -1, 1, g.SessionData.GateMin, g.SessionData.GateMax))
+}
+
+// Gate returns the gate of the cell at x,y in ticks, using the
+// user override when one is set
+func (g *Grid) Gate(x, y uint32) uint16 {
+	if g.SessionData.UserGate[x][y] != 0 {
+		return beatsToTicks(g.SessionData.UserGate[x][y])
+	}
+	if int(x) < len(g.Gates) {
+		return g.Gates[x]
+	}
+	return beatsToTicks(float64(g.SessionData.Release))
+}
+
+// beatsToTicks converts a length in beats to whole ticks, at least one
+func beatsToTicks(beats float64) uint16 {
+	ticks := math.Round(beats * metronome.TicksPerBeat)
+	if ticks < 1 {
+		return 1
+	}
+	return uint16(ticks)
+}
+
+// Velocity returns the velocity of the cell at x,y, using the
+// user override when one is set
+func (g *Grid) Velocity(x, y uint32) uint8 {
+	if g.SessionData.UserVelocity[x][y] != 0 {
+		return g.SessionData.UserVelocity[x][y]
+	}
+	if int(x) < len(g.Velocities) {
+		return g.Velocities[x]
+	}
+	return g.SessionData.Velocity
+}
+
+// AccentVelocity scales velocity by an accent level, AccentNormal leaving it unchanged
+func AccentVelocity(velocity, accent uint8) uint8 {
+	v := int(velocity) * int(accent) / int(generators.AccentNormal)
+	return uint8(helpers.ConstrainInt(v, 1, 127))
+}
+
 func (g *Grid) SetScale(scaleIndex int) {
 
 	// C   Db  D   Eb  E   F   F#  G   Ab  A   Bb   B
@@ -327,8 +826,8 @@ func (g *Grid) TurnNotesOn() {
 			writer.NoteOff(g.MidiWriter, note)
 		}
 		// Turn on
-		writer.NoteOn(g.MidiWriter, note, uint8(rand.Intn(50)+51))
-		g.Notes[note].beatsPlayed = 0
+		writer.NoteOn(g.MidiWriter, note, g.Notes[note].velocity)
+		g.Notes[note].ticksPlayed = 0
 		g.Notes[note].isPlaying = true
 		// Clean up, but keep allocated memory
 		// To keep the underlying array, slice the slice to zero length
@@ -336,13 +835,15 @@ func (g *Grid) TurnNotesOn() {
 	}
 }
 
+// TurnNotesOff counts a tick for each playing note and
+// turns off the notes that have reached their gate
 func (g *Grid) TurnNotesOff() {
 	for i, note := range g.Notes {
 		if note.isPlaying {
-			g.Notes[i].beatsPlayed++
-			if g.Notes[i].beatsPlayed >= note.release {
+			g.Notes[i].ticksPlayed++
+			if g.Notes[i].ticksPlayed >= note.gate {
 				writer.NoteOff(g.MidiWriter, note.index)
-				g.Notes[i].beatsPlayed = 0
+				g.Notes[i].ticksPlayed = 0
 				g.Notes[i].isPlaying = false
 			}
 		}
@@ -352,18 +853,242 @@ func (g *Grid) TurnNotesOff() {
 func (g *Grid) TurnAllNotesOff() {
 	for i, note := range g.Notes {
 		writer.NoteOff(g.MidiWriter, note.index)
-		g.Notes[i].beatsPlayed = 0
+		g.Notes[i].ticksPlayed = 0
 		g.Notes[i].isPlaying = false
 	}
 }
 
+// SendModulation sends a control change for each modulation lane due on
+// this tick, skipping values that have not changed since the last message
+func (g *Grid) SendModulation() {
+
+	lanes := g.SessionData.ModLanes
+
+	if len(g.ModValues) != len(lanes) {
+		g.ModValues = make([]int, len(lanes))
+		for i := range g.ModValues {
+			g.ModValues[i] = -1
+		}
+	}
+
+	beats := float64(g.Ticks) / metronome.TicksPerBeat
+
+	for i := range lanes {
+		if !lanes[i].Due(g.Ticks, metronome.TicksPerBeat) {
+			continue
+		}
+		value := lanes[i].Value(g.Noise, i, beats)
+		if int(value) == g.ModValues[i] {
+			continue
+		}
+		g.ModValues[i] = int(value)
+		g.MidiWriter.SetChannel(helpers.ConstrainUInt8(lanes[i].Channel, 1, 16) - 1)
+		writer.ControlChange(g.MidiWriter, helpers.ConstrainUInt8(lanes[i].CC, 0, 127), helpers.ConstrainUInt8(value, 0, 127))
+	}
+
+	g.MidiWriter.SetChannel(noteChannel)
+}
+
+// StrikeStep plays the notes of column x when its rhythm step is on and
+// passes its chance
+func (g *Grid) StrikeStep(x uint8) {
+	g.Ratchet = 1
+	g.ArpNotes = g.ArpNotes[:0]
+	step := int(x) % len(g.SessionData.UserPattern.Rhythm)
+	if g.SessionData.UserPattern.Rhythm[step] == 1 && g.Rand.Intn(100) < int(g.SessionData.UserPattern.Chance(step)) {
+		if g.SessionData.ArpMode != generators.ArpOff {
+			g.QueueColumn(x, g.SessionData.UserPattern.Accent(step), 1)
+			g.Arpeggiate()
+		} else {
+			g.Ratchet = g.SessionData.UserPattern.Ratchet(step)
+			g.QueueColumn(x, g.SessionData.UserPattern.Accent(step), g.Ratchet)
+			g.RatchetNotes = append(g.RatchetNotes[:0], g.NotesToStrike...)
+		}
+		g.StrikeTick = g.Ticks
+	}
+	g.TurnNotesOn()
+}
+
+// StrikeLayers plays the column under the playhead for each polymeter
+// layer with a hit starting on this tick. The column is that of the
+// current beat, whether or not swing or microtiming has struck it yet.
+func (g *Grid) StrikeLayers() {
+	column := (g.BeatIndex + uint8(len(g.Matrix)) - 1) % uint8(len(g.Matrix))
+	for i, layerPattern := range g.LayerPatterns {
+		if layerPattern == nil || i >= len(g.SessionData.Layers) {
+			continue
+		}
+		layer := g.SessionData.Layers[i]
+		if !layer.Starts(g.Ticks, metronome.TicksPerBeat) {
+			continue
+		}
+		step := int(layer.StepAt(g.Ticks, metronome.TicksPerBeat) % uint64(layerPattern.Len()))
+		if layerPattern.Rhythm[step] == 1 {
+			g.QueueColumn(column, layerPattern.Accent(step), 1)
+		}
+	}
+	g.TurnNotesOn()
+}
+
+// QueueColumn adds the notes of column x to those to strike, in the
+// order they were played into the grid. Notes already struck or queued
+// on this tick are skipped.
+func (g *Grid) QueueColumn(x uint8, accent, ratchet uint8) {
+	x = x % uint8(len(g.Matrix))
+	for _, y := range g.PlayedRows(x) {
+		note := helpers.ConstrainUInt8(g.SessionData.Low+g.Scale[y], 0, 127)
+		if g.Struck(note) {
+			continue
+		}
+		g.Notes[note].velocity = AccentVelocity(g.Velocity(uint32(x), y), accent)
+		g.Notes[note].gate = RatchetGate(g.Gate(uint32(x), y), ratchet)
+		g.NotesToStrike = append(g.NotesToStrike, note)
+	}
+}
+
+// Struck reports whether note is queued to strike or was struck on this
+// tick, before TurnNotesOff counts the tick
+func (g *Grid) Struck(note uint8) bool {
+	if g.Notes[note].isPlaying && g.Notes[note].ticksPlayed == 0 {
+		return true
+	}
+	for _, queued := range g.NotesToStrike {
+		if queued == note {
+			return true
+		}
+	}
+	return false
+}
+
+// PlayedRows returns the rows of column x with notes: the generated notes
+// from the bottom up, then the user's cells in the order they were placed
+func (g *Grid) PlayedRows(x uint8) []uint32 {
+	rows := []uint32{}
+	for y, val := range g.Matrix[x] {
+		if val == 1 || val == 2 {
+			rows = append(rows, uint32(y))
+		}
+	}
+	order := func(y uint32) uint32 {
+		if g.Matrix[x][y] == 1 {
+			return 0
+		}
+		return g.SessionData.UserOrder[x][y]
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return order(rows[i]) < order(rows[j])
+	})
+	return rows
+}
+
+// NextOrder returns the placing order for a new user cell in column x
+func (g *Grid) NextOrder(x uint32) uint32 {
+	next := uint32(1)
+	for _, order := range g.SessionData.UserOrder[x] {
+		if order >= next {
+			next = order + 1
+		}
+	}
+	return next
+}
+
+// ArpRate returns the number of arpeggio notes per beat
+func (g *Grid) ArpRate() uint8 {
+	return helpers.ConstrainUInt8(g.SessionData.ArpRate, 1, metronome.TicksPerBeat)
+}
+
+// Arpeggiate replaces the notes queued to strike with an arpeggio over
+// them and strikes its first note. Each note's gate is shortened to end
+// before the next arpeggio note, and notes an octave up take the velocity
+// and gate of the note they repeat.
+func (g *Grid) Arpeggiate() {
+	for _, note := range g.NotesToStrike {
+		g.Notes[note].gate = RatchetGate(g.Notes[note].gate, g.ArpRate())
+		for o := 1; o < int(g.SessionData.ArpOctaves) && int(note)+12*o <= 127; o++ {
+			g.Notes[int(note)+12*o].velocity = g.Notes[note].velocity
+			g.Notes[int(note)+12*o].gate = g.Notes[note].gate
+		}
+	}
+	g.ArpNotes = generators.Arpeggio(g.NotesToStrike, g.SessionData.ArpMode, g.SessionData.ArpOctaves, g.Rand)
+	g.NotesToStrike = g.NotesToStrike[:0]
+	if len(g.ArpNotes) > 0 {
+		g.NotesToStrike = append(g.NotesToStrike, g.ArpNotes[0])
+	}
+}
+
+// StrikeArpeggio strikes the next note of the arpeggio on each of the
+// ticks that divide its beat into ArpRate equal parts, cycling through
+// the arpeggio as many times as the rate allows
+func (g *Grid) StrikeArpeggio() {
+	if len(g.ArpNotes) == 0 {
+		return
+	}
+	elapsed := g.Ticks - g.StrikeTick
+	interval := uint64(metronome.TicksPerBeat / int(g.ArpRate()))
+	if elapsed > 0 && elapsed%interval == 0 && elapsed/interval < uint64(g.ArpRate()) {
+		index := (elapsed / interval) % uint64(len(g.ArpNotes))
+		g.NotesToStrike = append(g.NotesToStrike, g.ArpNotes[index])
+		g.TurnNotesOn()
+	}
+}
+
+// Timing returns how many ticks after its beat column x strikes, from the
+// swing amount and the column's microtiming offset. Negative is early.
+func (g *Grid) Timing(x uint8) int {
+	offset := 0.0
+	if int(x) < len(g.SessionData.Microtiming) {
+		offset = g.SessionData.Microtiming[x]
+	}
+	// Swing delays every second step, 75% landing it halfway to the next
+	if x%2 == 1 {
+		offset += (g.SessionData.Swing - 50) / 50
+	}
+	ticks := int(math.Round(offset * metronome.TicksPerBeat))
+	return helpers.ConstrainInt(ticks, -metronome.TicksPerBeat/2, metronome.TicksPerBeat-1)
+}
+
+// Retrigger strikes the notes of a ratcheted step again on the ticks that
+// divide its beat into Ratchet equal parts
+func (g *Grid) Retrigger() {
+	if g.Ratchet <= 1 {
+		return
+	}
+	elapsed := g.Ticks - g.StrikeTick
+	interval := uint64(metronome.TicksPerBeat / int(g.Ratchet))
+	if elapsed > 0 && elapsed%interval == 0 && elapsed/interval < uint64(g.Ratchet) {
+		g.NotesToStrike = append(g.NotesToStrike, g.RatchetNotes...)
+		g.TurnNotesOn()
+	}
+}
+
+// RatchetGate shortens a gate so that each repeat of a ratcheted note
+// ends before the next begins
+func RatchetGate(gate uint16, ratchet uint8) uint16 {
+	if ratchet <= 1 {
+		return gate
+	}
+	interval := uint16(metronome.TicksPerBeat / int(ratchet))
+	if gate >= interval {
+		return interval - 1
+	}
+	return gate
+}
+
+// Play starts playback, reseeding the variation RNG so a session with a
+// seed plays the same chances each time
 func (g *Grid) Play() {
+	g.Rand = rand.New(rand.NewSource(g.SessionData.Seed))
 	g.IsPlaying = true
 }
 
 func (g *Grid) Stop() {
 	g.IsPlaying = false
 	g.BeatIndex = 0
+	g.Ticks = 0
+	g.Ratchet = 1
+	g.ArpNotes = g.ArpNotes[:0]
+	g.PendingTick = 0
+	g.StruckEarly = false
 	g.TurnAllNotesOff()
 	g.SetPlayheadPosition()
 }
@@ -376,6 +1101,46 @@ func (g *Grid) Toggle() {
 	}
 }
 
+// Evolve advances the noise field by the drift rate at the end of each loop.
+// The grid is recomposed on the next frame, keeping user cells in place.
+func (g *Grid) Evolve() {
+	if g.SessionData.Drift != 0 {
+		g.SessionData.EvolveY += g.SessionData.Drift
+		g.SignalReceived = true
+	}
+	evolved := false
+	// Automata advance one generation each loop
+	if g.SessionData.Melody == generators.MelodyAutomaton || g.SessionData.Rhythm == generators.KindAutomaton {
+		g.SessionData.CAGeneration = (g.SessionData.CAGeneration + 1) % (generators.MaxGeneration + 1)
+		evolved = true
+	}
+	// The turing register shifts once for each step of the loop, so the
+	// next loop carries on where this one ended
+	if g.SessionData.Melody == generators.MelodyTuring || g.SessionData.Rhythm == generators.KindTuring {
+		turing := g.SessionData.Turing()
+		for x := uint32(0); x < g.SessionData.XSteps; x++ {
+			turing.Register = turing.Next(g.Rand)
+		}
+		g.SessionData.TuringRegister = turing.Register
+		evolved = true
+	}
+	// The controls follow the generators that moved on
+	if evolved {
+		g.SignalReceived = true
+		g.SendToOutputChannels(signals.Signal{Label: "evolved"})
+	}
+}
+
+// Layer returns the polymeter layer selected on the control board
+func (g *Grid) Layer() *generators.Layer {
+	return &g.SessionData.Layers[int(g.SessionData.Layer)%len(g.SessionData.Layers)]
+}
+
+// ModLane returns the modulation lane selected on the control board
+func (g *Grid) ModLane() *modulation.Lane {
+	return &g.SessionData.ModLanes[int(g.SessionData.ModLane)%len(g.SessionData.ModLanes)]
+}
+
 func (g *Grid) ListenToInputCtrlChannel() {
 	go func() {
 		for {
@@ -393,6 +1158,8 @@ func (g *Grid) ListenToInputCtrlChannel() {
 				g.SetScale(4)
 			case "pentatonic":
 				g.SetScale(5)
+			case "train":
+				g.TrainMarkov()
 			case "play":
 				g.Play()
 			case "stop":
@@ -413,6 +1180,70 @@ func (g *Grid) ListenToInputCtrlChannel() {
 				g.SessionData.YSteps = uint32(signal.Value)
 			case "pos":
 				g.SessionData.Offset = uint32(signal.Value)
+			case "seed":
+				g.SessionData.Seed = int64(signal.Value)
+			case "source":
+				g.SessionData.Source = noise.Source(signal.Value)
+			case "loop":
+				g.SessionData.Loop = signal.Value == 1
+			case "vel":
+				g.SessionData.VelocityMode = uint8(signal.Value)
+			case "vfix":
+				g.SessionData.Velocity = uint8(signal.Value)
+			case "vfreq":
+				g.SessionData.VelocityFreq = signal.Value
+			case "voff":
+				g.SessionData.VelocityOffset = uint32(signal.Value)
+			case "vmin":
+				g.SessionData.VelocityMin = uint8(signal.Value)
+			case "vmax":
+				g.SessionData.VelocityMax = uint8(signal.Value)
+			case "gate":
+				g.SessionData.GateMode = uint8(signal.Value)
+			case "gfreq":
+				g.SessionData.GateFreq = signal.Value
+			case "goff":
+				g.SessionData.GateOffset = uint32(signal.Value)
+			case "gmin":
+				g.SessionData.GateMin = signal.Value
+			case "gmax":
+				g.SessionData.GateMax = signal.Value
+			case "lane":
+				g.SessionData.ModLane = uint8(signal.Value)
+			case "mod":
+				g.ModLane().On = signal.Value == 1
+			case "cc":
+				g.ModLane().CC = uint8(signal.Value)
+			case "chan":
+				g.ModLane().Channel = uint8(signal.Value)
+			case "rate":
+				g.ModLane().Rate = uint8(signal.Value)
+			case "min":
+				g.ModLane().Min = uint8(signal.Value)
+			case "max":
+				g.ModLane().Max = uint8(signal.Value)
+			case "mfreq":
+				g.ModLane().Frequency = signal.Value
+			case "moff":
+				g.ModLane().Offset = uint32(signal.Value)
+			case "mocts":
+				g.ModLane().Octaves = uint8(signal.Value)
+			case "voices":
+				g.SessionData.Voices = uint8(signal.Value)
+			case "spread":
+				g.SessionData.VoiceSpread = signal.Value
+			case "harmony":
+				g.SessionData.VoiceMode = uint8(signal.Value)
+			case "interval":
+				g.SessionData.VoiceInterval = uint8(signal.Value)
+			case "drift":
+				g.SessionData.Drift = signal.Value
+			case "fractal":
+				g.SessionData.Fractal = simplexnoise.Fractal(signal.Value)
+			case "warp":
+				g.SessionData.WarpAmount = signal.Value
+			case "wfreq":
+				g.SessionData.WarpFrequency = signal.Value
 			case "low":
 				g.SessionData.Low = uint8(signal.Value)
 			case "rel":
@@ -425,6 +1256,68 @@ func (g *Grid) ListenToInputCtrlChannel() {
 				g.SessionData.R = uint8(signal.Value)
 			case "g":
 				g.SessionData.G = signal.Value
+			case "rhythm":
+				g.SessionData.Rhythm = generators.Kind(signal.Value)
+			case "algo":
+				g.SessionData.Algorithm = generators.Algorithm(signal.Value)
+			case "dens":
+				g.SessionData.RhythmDensity = signal.Value
+			case "clave":
+				g.SessionData.RhythmTimeline = uint8(signal.Value)
+			case "thresh":
+				g.SessionData.RhythmThreshold = signal.Value
+			case "accent":
+				g.SessionData.AccentN = uint8(signal.Value)
+			case "arot":
+				g.SessionData.AccentR = uint8(signal.Value)
+			case "alvl":
+				g.SessionData.AccentLevel = uint8(signal.Value)
+			case "layer":
+				g.SessionData.Layer = uint8(signal.Value)
+			case "lon":
+				g.Layer().On = signal.Value == 1
+			case "ln":
+				g.Layer().N = uint8(signal.Value)
+			case "lk":
+				g.Layer().K = uint8(signal.Value)
+			case "lr":
+				g.Layer().Rotation = uint8(signal.Value)
+			case "lnum":
+				g.Layer().Num = uint8(signal.Value)
+			case "lden":
+				g.Layer().Den = uint8(signal.Value)
+			case "melody":
+				g.SessionData.Melody = generators.Melody(signal.Value)
+			case "rule":
+				g.SessionData.CARule = uint8(signal.Value)
+			case "cseed":
+				g.SessionData.CASeed = int64(signal.Value)
+			case "cgen":
+				g.SessionData.CAGeneration = uint32(signal.Value)
+			case "order":
+				g.SessionData.Markov.SetOrder(uint8(signal.Value))
+			case "mrand":
+				g.SessionData.MarkovRandomness = signal.Value
+			case "flip":
+				g.SessionData.TuringFlip = signal.Value
+			case "lock":
+				g.SessionData.TuringLocked = signal.Value == 1
+			case "reg":
+				g.SessionData.TuringRegister = uint32(signal.Value)
+			case "iter":
+				g.SessionData.LSystemDepth = uint8(signal.Value)
+			case "swing":
+				g.SessionData.Swing = signal.Value
+			case "chance":
+				g.SessionData.Chance = uint8(signal.Value)
+			case "ratch":
+				g.SessionData.Ratchet = uint8(signal.Value)
+			case "arp":
+				g.SessionData.ArpMode = generators.ArpMode(signal.Value)
+			case "aoct":
+				g.SessionData.ArpOctaves = uint8(signal.Value)
+			case "arate":
+				g.SessionData.ArpRate = uint8(signal.Value)
 			default:
 			}
 			g.SignalReceived = true
@@ -436,24 +1329,59 @@ func (g *Grid) ListenToInputBeatChannel() {
 	go func() {
 		for {
 			beatSignal := <-g.InputBeatChannel
-			if g.IsPlaying {
-				if g.SessionData.UserPattern.Rhythm[g.BeatIndex%uint8(len(g.SessionData.UserPattern.Rhythm))] == 1 {
-					for y, val := range g.Matrix[g.BeatIndex%uint8(len(g.Matrix))] {
-						if val == 1 || val == 2 {
-							note := helpers.ConstrainUInt8(g.SessionData.Low+g.Scale[y], 0, 127)
-							g.NotesToStrike = append(g.NotesToStrike, note)
-						}
+			// Ticks count from the first beat after play, so the beat
+			// under the playhead is always the one before BeatIndex
+			if g.IsPlaying && (g.Ticks > 0 || beatSignal.Label == "beat") {
+				g.SendModulation()
+				g.TurnNotesOff()
+				if beatSignal.Label == "beat" {
+					// The step under the playhead strikes now, later when
+					// delayed, or has already struck when pushed early
+					timing := g.Timing(g.BeatIndex)
+					switch {
+					case g.StruckEarly:
+						g.StruckEarly = false
+					case timing <= 0:
+						g.StrikeStep(g.BeatIndex)
+					default:
+						g.PendingStep, g.PendingTick = g.BeatIndex, timing
+					}
+					g.SetPlayheadPosition()
+					g.BeatIndex = (g.BeatIndex + uint8(beatSignal.Value)) % uint8(len(g.Matrix))
+					if g.BeatIndex == 0 {
+						g.Evolve()
+					}
+				} else {
+					tick := int(beatSignal.Value)
+					if g.PendingTick == tick {
+						g.StrikeStep(g.PendingStep)
+						g.PendingTick = 0
+					}
+					if timing := g.Timing(g.BeatIndex); timing < 0 && tick == metronome.TicksPerBeat+timing {
+						g.StrikeStep(g.BeatIndex)
+						g.StruckEarly = true
 					}
 				}
-				g.TurnNotesOff()
-				g.TurnNotesOn()
-				g.SetPlayheadPosition()
-				g.BeatIndex = (g.BeatIndex + uint8(beatSignal.Value)) % uint8(len(g.Matrix))
+				g.StrikeLayers()
+				g.Retrigger()
+				g.StrikeArpeggio()
+				g.Ticks++
 			}
 		}
 	}()
 }
 
+func (g *Grid) AddOutputChannel(outputChannel chan signals.Signal) {
+	g.OutputChannels = append(g.OutputChannels, outputChannel)
+}
+
+func (g *Grid) SendToOutputChannels(signal signals.Signal) {
+	// Send grid signal to all subscribers
+	for index := range g.OutputChannels {
+		g.OutputChannels[index] <- signal
+	}
+}
+
 func (g *Grid) ListenToInputSessionChannel() {
 	go func() {
 		for {